@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/megakuul/leaderboard/api/friend/fetch/query"
+)
+
+type FetchResponse struct {
+	Message string               `json:"message"`
+	Friends []query.FriendOutput `json:"friends"`
+}
+
+func FetchHandler(dynamoClient *dynamodb.Client) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		response, code, err := runFetchHandler(dynamoClient, &request, ctx)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: code,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       err.Error(),
+			}, nil
+		}
+		serializedResponse, err := json.Marshal(&response)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: http.StatusInternalServerError,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       "failed to serialize response",
+			}, nil
+		}
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode: code,
+			Headers:    map[string]string{"Content-Type": "application/json"},
+			Body:       string(serializedResponse),
+		}, nil
+	}
+}
+
+func runFetchHandler(dynamoClient *dynamodb.Client, request *events.APIGatewayV2HTTPRequest, ctx context.Context) (*FetchResponse, int, error) {
+	sub := request.RequestContext.Authorizer.JWT.Claims["sub"]
+	if sub == "" {
+		return nil, http.StatusUnprocessableEntity, fmt.Errorf("invalid sub claim in the ID token")
+	}
+
+	friends, err := query.FetchFriends(dynamoClient, ctx, FRIENDTABLE, USERTABLE, sub)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch friends: %v", err)
+	}
+
+	return &FetchResponse{
+		Message: "successfully fetched friends",
+		Friends: friends,
+	}, http.StatusOK, nil
+}
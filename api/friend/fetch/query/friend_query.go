@@ -0,0 +1,75 @@
+package query
+
+import (
+	"context"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// FetchFriends fetches the friends and pending friend requests of the subject sorted by status and username.
+// the usernames are resolved from the user table, as users can be renamed after the request was sent.
+func FetchFriends(dynamoClient *dynamodb.Client, ctx context.Context, friendTableName, userTableName, subject string) ([]FriendOutput, error) {
+	relations := []FriendOutput{}
+	var lastEvaluatedKey map[string]types.AttributeValue
+	for {
+		output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
+			TableName: aws.String(friendTableName),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":subject": &types.AttributeValueMemberS{Value: subject},
+			},
+			KeyConditionExpression: aws.String("subject = :subject"),
+			ExclusiveStartKey:      lastEvaluatedKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		var page []FriendOutput
+		if err := attributevalue.UnmarshalListOfMaps(output.Items, &page); err != nil {
+			return nil, err
+		}
+		relations = append(relations, page...)
+
+		lastEvaluatedKey = output.LastEvaluatedKey
+		if len(lastEvaluatedKey) < 1 {
+			break
+		}
+	}
+
+	keys := []map[string]types.AttributeValue{}
+	for _, relation := range relations {
+		keys = append(keys, map[string]types.AttributeValue{
+			"subject": &types.AttributeValueMemberS{Value: relation.FriendSubject},
+		})
+	}
+	items, err := batchGetItems(dynamoClient, ctx, userTableName, keys)
+	if err != nil {
+		return nil, err
+	}
+	var users []userOutput
+	if err := attributevalue.UnmarshalListOfMaps(items, &users); err != nil {
+		return nil, err
+	}
+	usernames := map[string]string{}
+	for _, user := range users {
+		usernames[user.Subject] = user.Username
+	}
+
+	friends := []FriendOutput{}
+	for _, relation := range relations {
+		// relations of users that were deleted in the meantime are skipped.
+		if relation.Username = usernames[relation.FriendSubject]; relation.Username != "" {
+			friends = append(friends, relation)
+		}
+	}
+	sort.SliceStable(friends, func(i, j int) bool {
+		if friends[i].Status != friends[j].Status {
+			return friends[i].Status < friends[j].Status
+		}
+		return friends[i].Username < friends[j].Username
+	})
+	return friends, nil
+}
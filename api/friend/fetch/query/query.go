@@ -0,0 +1,23 @@
+// contains wrappers for database query functions.
+// main purpose is to abstract some boilerplate code
+// away from the handler.
+package query
+
+const (
+	// maximum number of keys dynamodb accepts in one batch get.
+	MAX_BATCH_GET = 100
+)
+
+// FriendOutput is a friend or a pending friend request of the user.
+// status is "accepted" for friends, "outgoing" for sent and "incoming" for received requests.
+type FriendOutput struct {
+	FriendSubject string `dynamodbav:"friend_subject" json:"-"`
+	Username      string `dynamodbav:"-" json:"username"`
+	Status        string `dynamodbav:"status" json:"status"`
+	UpdatedAt     int64  `dynamodbav:"updated_at" json:"updated_at"`
+}
+
+type userOutput struct {
+	Subject  string `dynamodbav:"subject"`
+	Username string `dynamodbav:"username"`
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/megakuul/leaderboard/api/friend/remove/query"
+	"github.com/megakuul/leaderboard/api/friend/remove/remove"
+)
+
+func RemoveHandler(dynamoClient *dynamodb.Client) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		response, code, err := runRemoveHandler(dynamoClient, &request, ctx)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: code,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       err.Error(),
+			}, nil
+		}
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode: code,
+			Headers:    map[string]string{"Content-Type": "text/plain"},
+			Body:       response,
+		}, nil
+	}
+}
+
+func runRemoveHandler(dynamoClient *dynamodb.Client, request *events.APIGatewayV2HTTPRequest, ctx context.Context) (string, int, error) {
+	sub := request.RequestContext.Authorizer.JWT.Claims["sub"]
+	if sub == "" {
+		return "", http.StatusUnprocessableEntity, fmt.Errorf("invalid sub claim in the ID token")
+	}
+
+	username, ok := request.QueryStringParameters["username"]
+	if !ok || username == "" {
+		return "", http.StatusBadRequest, fmt.Errorf("missing query parameter 'username'")
+	}
+
+	friend, err := query.FetchByUsername(dynamoClient, ctx, USERTABLE, username)
+	if err != nil {
+		return "", http.StatusNotFound, fmt.Errorf("failed to fetch friend: %v", err)
+	}
+
+	relation, err := query.FetchRelation(dynamoClient, ctx, FRIENDTABLE, sub, friend.Subject)
+	if err != nil {
+		return "", http.StatusInternalServerError, fmt.Errorf("failed to fetch friendship: %v", err)
+	}
+	if relation == nil {
+		return "", http.StatusNotFound, fmt.Errorf("no friendship or friend request with this user")
+	}
+
+	// removes friends, declines incoming and cancels outgoing requests.
+	if err := remove.RemoveRelation(dynamoClient, ctx, FRIENDTABLE, sub, friend.Subject); err != nil {
+		return "", http.StatusInternalServerError, fmt.Errorf("failed to remove friendship: %v", err)
+	}
+	return fmt.Sprintf("successfully removed %s friendship with %s", relation.Status, username), http.StatusOK, nil
+}
@@ -0,0 +1,33 @@
+package query
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// FetchRelation fetches the relation of the subject to the friend. nil is returned if there is no relation.
+func FetchRelation(dynamoClient *dynamodb.Client, ctx context.Context, tableName, subject, friendSubject string) (*FriendOutput, error) {
+	output, err := dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"subject":        &types.AttributeValueMemberS{Value: subject},
+			"friend_subject": &types.AttributeValueMemberS{Value: friendSubject},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if output.Item == nil {
+		return nil, nil
+	}
+	var relation FriendOutput
+	if err := attributevalue.UnmarshalMap(output.Item, &relation); err != nil {
+		return nil, err
+	}
+	return &relation, nil
+}
@@ -0,0 +1,18 @@
+// contains wrappers for database query functions.
+// main purpose is to abstract some boilerplate code
+// away from the handler.
+package query
+
+type UserOutput struct {
+	Subject  string `dynamodbav:"subject"`
+	Username string `dynamodbav:"username"`
+}
+
+// FriendOutput is the relation of the user (subject) to the friend (friend_subject).
+// every relation is stored twice, once from the perspective of each user.
+type FriendOutput struct {
+	Subject       string `dynamodbav:"subject"`
+	FriendSubject string `dynamodbav:"friend_subject"`
+	Status        string `dynamodbav:"status"`
+	UpdatedAt     int64  `dynamodbav:"updated_at"`
+}
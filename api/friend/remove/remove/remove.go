@@ -0,0 +1,39 @@
+// contains wrappers for database delete functions.
+// main purpose is to abstract some boilerplate code
+// away from the handler.
+package remove
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// RemoveRelation removes the friendship (or pending request) of both users in one transaction.
+func RemoveRelation(dynamoClient *dynamodb.Client, ctx context.Context, tableName, subject, friendSubject string) error {
+	_, err := dynamoClient.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Delete: &types.Delete{
+					TableName: aws.String(tableName),
+					Key: map[string]types.AttributeValue{
+						"subject":        &types.AttributeValueMemberS{Value: subject},
+						"friend_subject": &types.AttributeValueMemberS{Value: friendSubject},
+					},
+				},
+			},
+			{
+				Delete: &types.Delete{
+					TableName: aws.String(tableName),
+					Key: map[string]types.AttributeValue{
+						"subject":        &types.AttributeValueMemberS{Value: friendSubject},
+						"friend_subject": &types.AttributeValueMemberS{Value: subject},
+					},
+				},
+			},
+		},
+	})
+	return err
+}
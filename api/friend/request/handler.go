@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/megakuul/leaderboard/api/friend/request/query"
+	"github.com/megakuul/leaderboard/api/friend/request/update"
+)
+
+type RequestResponse struct {
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
+func RequestHandler(dynamoClient *dynamodb.Client) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		response, code, err := runRequestHandler(dynamoClient, &request, ctx)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: code,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       err.Error(),
+			}, nil
+		}
+		serializedResponse, err := json.Marshal(&response)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: http.StatusInternalServerError,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       "failed to serialize response",
+			}, nil
+		}
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode: code,
+			Headers:    map[string]string{"Content-Type": "application/json"},
+			Body:       string(serializedResponse),
+		}, nil
+	}
+}
+
+func runRequestHandler(dynamoClient *dynamodb.Client, request *events.APIGatewayV2HTTPRequest, ctx context.Context) (*RequestResponse, int, error) {
+	sub := request.RequestContext.Authorizer.JWT.Claims["sub"]
+	if sub == "" {
+		return nil, http.StatusUnprocessableEntity, fmt.Errorf("invalid sub claim in the ID token")
+	}
+
+	username, ok := request.QueryStringParameters["username"]
+	if !ok || username == "" {
+		return nil, http.StatusBadRequest, fmt.Errorf("missing query parameter 'username'")
+	}
+
+	user, err := query.FetchBySubject(dynamoClient, ctx, USERTABLE, sub)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch user: %v", err)
+	}
+	if user == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("user must be registered (updated) before adding friends")
+	}
+
+	friend, err := query.FetchByUsername(dynamoClient, ctx, USERTABLE, username)
+	if err != nil {
+		return nil, http.StatusNotFound, fmt.Errorf("failed to fetch friend: %v", err)
+	}
+	if friend.Subject == sub {
+		return nil, http.StatusBadRequest, fmt.Errorf("users can not befriend themselves")
+	}
+	if friend.Disabled {
+		return nil, http.StatusNotFound, fmt.Errorf("failed to fetch friend: user not found")
+	}
+
+	relation, err := query.FetchRelation(dynamoClient, ctx, FRIENDTABLE, sub, friend.Subject)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch friendship: %v", err)
+	}
+	if relation != nil && relation.Status != query.FRIEND_STATUS_INCOMING {
+		return &RequestResponse{
+			Message: "friendship already exists",
+			Status:  relation.Status,
+		}, http.StatusOK, nil
+	}
+
+	// the limit bounds the friends leaderboard, which fetches all friends at once.
+	friendCount, err := query.CountFriends(dynamoClient, ctx, FRIENDTABLE, sub)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to count friends: %v", err)
+	}
+	if friendCount >= MAX_FRIENDS {
+		return nil, http.StatusBadRequest, fmt.Errorf("friend limit reached: users can have at most %d friends and requests", MAX_FRIENDS)
+	}
+
+	// if the user already sent a request to the caller, the request is accepted and both users become friends.
+	if relation != nil {
+		err = update.AcceptRequest(dynamoClient, ctx, FRIENDTABLE, sub, friend.Subject)
+		if errors.Is(err, update.ErrConcurrentUpdate) {
+			return nil, http.StatusConflict, fmt.Errorf("failed to accept friend request: %v", err)
+		} else if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to accept friend request: %v", err)
+		}
+		return &RequestResponse{
+			Message: "successfully accepted friend request",
+			Status:  query.FRIEND_STATUS_ACCEPTED,
+		}, http.StatusOK, nil
+	}
+
+	err = update.SendRequest(dynamoClient, ctx, FRIENDTABLE, sub, friend.Subject)
+	if errors.Is(err, update.ErrConcurrentUpdate) {
+		return nil, http.StatusConflict, fmt.Errorf("failed to send friend request: %v", err)
+	} else if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to send friend request: %v", err)
+	}
+	return &RequestResponse{
+		Message: "successfully sent friend request",
+		Status:  query.FRIEND_STATUS_OUTGOING,
+	}, http.StatusOK, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+var (
+	REGION      = os.Getenv("AWS_REGION")
+	USERTABLE   = os.Getenv("USERTABLE")
+	FRIENDTABLE = os.Getenv("FRIENDTABLE")
+	MAX_FRIENDS = 100 // default 100
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatalf("ERROR INITIALIZATION: %v\n", err)
+	}
+}
+
+func run() error {
+	awsConfig, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(REGION))
+	if err != nil {
+		return fmt.Errorf("failed to load aws config: %v", err)
+	}
+	dynamoClient := dynamodb.NewFromConfig(awsConfig)
+
+	if maxFriends, err := strconv.Atoi(os.Getenv("MAX_FRIENDS")); err == nil {
+		MAX_FRIENDS = maxFriends
+	}
+
+	lambda.Start(RequestHandler(dynamoClient))
+	return nil
+}
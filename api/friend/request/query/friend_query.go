@@ -0,0 +1,67 @@
+package query
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// FetchRelation fetches the relation of the subject to the friend. nil is returned if there is no relation.
+func FetchRelation(dynamoClient *dynamodb.Client, ctx context.Context, tableName, subject, friendSubject string) (*FriendOutput, error) {
+	output, err := dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"subject":        &types.AttributeValueMemberS{Value: subject},
+			"friend_subject": &types.AttributeValueMemberS{Value: friendSubject},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if output.Item == nil {
+		return nil, nil
+	}
+	var relation FriendOutput
+	if err := attributevalue.UnmarshalMap(output.Item, &relation); err != nil {
+		return nil, err
+	}
+	return &relation, nil
+}
+
+// CountFriends counts the friends and the outgoing friend requests of the subject.
+// incoming requests are not counted, otherwise other users could exhaust the limit of the subject.
+func CountFriends(dynamoClient *dynamodb.Client, ctx context.Context, tableName, subject string) (int, error) {
+	count := 0
+	var lastEvaluatedKey map[string]types.AttributeValue
+	for {
+		output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
+			TableName: aws.String(tableName),
+			ExpressionAttributeNames: map[string]string{
+				"#subject": "subject",
+				"#status":  "status",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":subject":  &types.AttributeValueMemberS{Value: subject},
+				":incoming": &types.AttributeValueMemberS{Value: FRIEND_STATUS_INCOMING},
+			},
+			KeyConditionExpression: aws.String("#subject = :subject"),
+			FilterExpression:       aws.String("#status <> :incoming"),
+			Select:                 types.SelectCount,
+			ExclusiveStartKey:      lastEvaluatedKey,
+		})
+		if err != nil {
+			return 0, err
+		}
+		count += int(output.Count)
+
+		lastEvaluatedKey = output.LastEvaluatedKey
+		if len(lastEvaluatedKey) < 1 {
+			break
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,103 @@
+// contains wrappers for databsae update functions.
+// main purpose is to abstract some boilerplate code
+// away from the handler.
+package update
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/megakuul/leaderboard/api/friend/request/query"
+)
+
+// ErrConcurrentUpdate indicates that the relation was changed by a concurrent request of one of the users.
+var ErrConcurrentUpdate = errors.New("friendship was modified concurrently, please retry")
+
+// SendRequest creates the friend request from the subject to the friend.
+// the relation is written for both users in one transaction (outgoing for the subject, incoming for the friend).
+func SendRequest(dynamoClient *dynamodb.Client, ctx context.Context, tableName, subject, friendSubject string) error {
+	updatedAt := &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Unix(), 10)}
+	_, err := dynamoClient.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Put: &types.Put{
+					TableName: aws.String(tableName),
+					Item: map[string]types.AttributeValue{
+						"subject":        &types.AttributeValueMemberS{Value: subject},
+						"friend_subject": &types.AttributeValueMemberS{Value: friendSubject},
+						"status":         &types.AttributeValueMemberS{Value: query.FRIEND_STATUS_OUTGOING},
+						"updated_at":     updatedAt,
+					},
+					ConditionExpression: aws.String("attribute_not_exists(subject)"),
+				},
+			},
+			{
+				Put: &types.Put{
+					TableName: aws.String(tableName),
+					Item: map[string]types.AttributeValue{
+						"subject":        &types.AttributeValueMemberS{Value: friendSubject},
+						"friend_subject": &types.AttributeValueMemberS{Value: subject},
+						"status":         &types.AttributeValueMemberS{Value: query.FRIEND_STATUS_INCOMING},
+						"updated_at":     updatedAt,
+					},
+					ConditionExpression: aws.String("attribute_not_exists(subject)"),
+				},
+			},
+		},
+	})
+	if err != nil {
+		var cancelErr *types.TransactionCanceledException
+		if errors.As(err, &cancelErr) {
+			return ErrConcurrentUpdate
+		}
+		return err
+	}
+	return nil
+}
+
+// AcceptRequest accepts the friend request the friend sent to the subject.
+// both relations are only updated if they are still pending.
+func AcceptRequest(dynamoClient *dynamodb.Client, ctx context.Context, tableName, subject, friendSubject string) error {
+	updatedAt := &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Unix(), 10)}
+	accept := func(subject, friendSubject, pendingStatus string) types.TransactWriteItem {
+		return types.TransactWriteItem{
+			Update: &types.Update{
+				TableName: aws.String(tableName),
+				Key: map[string]types.AttributeValue{
+					"subject":        &types.AttributeValueMemberS{Value: subject},
+					"friend_subject": &types.AttributeValueMemberS{Value: friendSubject},
+				},
+				ExpressionAttributeNames: map[string]string{
+					"#status":     "status",
+					"#updated_at": "updated_at",
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":pending":    &types.AttributeValueMemberS{Value: pendingStatus},
+					":accepted":   &types.AttributeValueMemberS{Value: query.FRIEND_STATUS_ACCEPTED},
+					":updated_at": updatedAt,
+				},
+				ConditionExpression: aws.String("#status = :pending"),
+				UpdateExpression:    aws.String("SET #status = :accepted, #updated_at = :updated_at"),
+			},
+		}
+	}
+	_, err := dynamoClient.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			accept(subject, friendSubject, query.FRIEND_STATUS_INCOMING),
+			accept(friendSubject, subject, query.FRIEND_STATUS_OUTGOING),
+		},
+	})
+	if err != nil {
+		var cancelErr *types.TransactionCanceledException
+		if errors.As(err, &cancelErr) {
+			return ErrConcurrentUpdate
+		}
+		return err
+	}
+	return nil
+}
@@ -3,19 +3,34 @@ package main
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/google/uuid"
+	"github.com/megakuul/leaderboard/api/game/add/auth"
+	"github.com/megakuul/leaderboard/api/game/add/outbox"
 	"github.com/megakuul/leaderboard/api/game/add/put"
 	"github.com/megakuul/leaderboard/api/game/add/query"
+	"github.com/megakuul/leaderboard/api/game/add/quorum"
 	"github.com/megakuul/leaderboard/api/game/add/rating"
-	"github.com/megakuul/leaderboard/api/game/add/sender"
+)
+
+const (
+	MAX_NAME_LENGTH     = 50
+	MAX_LOCATION_LENGTH = 50
+	MAX_NOTES_LENGTH    = 500
+	// played_at timestamps are accepted slightly in the future to compensate for client clock drift.
+	MAX_PLAYED_AT_DRIFT        = 5 * time.Minute
+	MAX_IDEMPOTENCY_KEY_LENGTH = 255
+	ADD_SUCCESS_MESSAGE        = "successfully added game. ensure all players confirm the game to validate it"
 )
 
 type Participant struct {
@@ -27,6 +42,11 @@ type Participant struct {
 
 type AddRequest struct {
 	PlacementPoints int           `json:"placement_points"`
+	QuorumPolicy    string        `json:"quorum_policy"`
+	Name            string        `json:"name"`
+	Location        string        `json:"location"`
+	Notes           string        `json:"notes"`
+	PlayedAt        string        `json:"played_at"`
 	Participants    []Participant `dynamodbav:"participants" json:"participants"`
 }
 
@@ -35,9 +55,9 @@ type AddResponse struct {
 	GameId  string `json:"gameid"`
 }
 
-func AddHandler(dynamoClient *dynamodb.Client, sesClient *sesv2.Client) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+func AddHandler(dynamoClient *dynamodb.Client) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
 	return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
-		response, code, err := runAddHandler(dynamoClient, sesClient, &request, ctx)
+		response, code, err := runAddHandler(dynamoClient, &request, ctx)
 		if err != nil {
 			return events.APIGatewayV2HTTPResponse{
 				StatusCode: code,
@@ -61,12 +81,82 @@ func AddHandler(dynamoClient *dynamodb.Client, sesClient *sesv2.Client) func(con
 	}
 }
 
-func runAddHandler(dynamoClient *dynamodb.Client, sesClient *sesv2.Client, request *events.APIGatewayV2HTTPRequest, ctx context.Context) (*AddResponse, int, error) {
+func runAddHandler(dynamoClient *dynamodb.Client, request *events.APIGatewayV2HTTPRequest, ctx context.Context) (*AddResponse, int, error) {
+	sub := request.RequestContext.Authorizer.JWT.Claims["sub"]
+	if sub == "" {
+		return nil, http.StatusUnprocessableEntity, fmt.Errorf("invalid sub claim in the ID token")
+	}
+
+	// retried requests with the same idempotency key return the original response instead of inserting the game again.
+	var idempotencyInput *put.IdempotencyInput
+	idempotencyFingerprint := ""
+	if idempotencyKey := request.Headers["idempotency-key"]; idempotencyKey != "" {
+		if len(idempotencyKey) > MAX_IDEMPOTENCY_KEY_LENGTH {
+			return nil, http.StatusBadRequest, fmt.Errorf("maximum idempotency key length is %d", MAX_IDEMPOTENCY_KEY_LENGTH)
+		}
+		bodyHash := sha256.Sum256([]byte(request.Body))
+		idempotencyFingerprint = hex.EncodeToString(bodyHash[:])
+
+		// keys are scoped to the submitter, so that different users can't collide or replay each other's responses.
+		scopedKey := fmt.Sprintf("%s#%s", sub, idempotencyKey)
+		record, err := query.FetchByIdempotencyKey(dynamoClient, ctx, IDEMPOTENCYTABLE, scopedKey, int(time.Now().Unix()))
+		if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to lookup idempotency key: %v", err)
+		}
+		if record != nil {
+			return replayIdempotentResponse(record, idempotencyFingerprint)
+		}
+		idempotencyInput = &put.IdempotencyInput{
+			Key:         scopedKey,
+			Fingerprint: idempotencyFingerprint,
+			Message:     ADD_SUCCESS_MESSAGE,
+			ExpiresIn:   int(time.Now().Add(time.Duration(IDEMPOTENCY_TTL_HOURS) * time.Hour).Unix()),
+		}
+	}
+
 	var req AddRequest
 	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
 		return nil, http.StatusBadRequest, fmt.Errorf("failed to deserialize request: invalid body")
 	}
 
+	if req.QuorumPolicy != "" && !quorum.IsValid(req.QuorumPolicy) {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid quorum policy: %s", req.QuorumPolicy)
+	}
+
+	if len(req.Name) > MAX_NAME_LENGTH {
+		return nil, http.StatusBadRequest, fmt.Errorf("maximum name length is %d", MAX_NAME_LENGTH)
+	}
+
+	if len(req.Location) > MAX_LOCATION_LENGTH {
+		return nil, http.StatusBadRequest, fmt.Errorf("maximum location length is %d", MAX_LOCATION_LENGTH)
+	}
+
+	if len(req.Notes) > MAX_NOTES_LENGTH {
+		return nil, http.StatusBadRequest, fmt.Errorf("maximum notes length is %d", MAX_NOTES_LENGTH)
+	}
+
+	playedAt := time.Now().UTC()
+	if req.PlayedAt != "" {
+		var err error
+		playedAt, err = time.Parse(time.RFC3339, req.PlayedAt)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid played_at timestamp: expected RFC3339 format with timezone")
+		}
+		if playedAt.After(time.Now().Add(MAX_PLAYED_AT_DRIFT)) {
+			return nil, http.StatusBadRequest, fmt.Errorf("played_at timestamp must not be in the future")
+		}
+		if playedAt.Before(time.Now().Add(-time.Duration(MAX_PLAYED_AT_AGE_DAYS) * 24 * time.Hour)) {
+			return nil, http.StatusBadRequest, fmt.Errorf("played_at timestamp must not be older than %d days", MAX_PLAYED_AT_AGE_DAYS)
+		}
+	}
+
+	gameMetadata := put.MetadataInput{
+		Name:     req.Name,
+		Location: req.Location,
+		Notes:    req.Notes,
+		PlayedAt: playedAt,
+	}
+
 	if len(req.Participants) < 2 {
 		return nil, http.StatusBadRequest, fmt.Errorf("minimum number of participants is 2")
 	}
@@ -93,10 +183,25 @@ func runAddHandler(dynamoClient *dynamodb.Client, sesClient *sesv2.Client, reque
 		})
 	}
 
+	// only participants are allowed to submit games, admins and referees can submit on behalf of others.
+	if !auth.IsGroupMember(request.RequestContext.Authorizer.JWT.Claims, ADMINGROUP) &&
+		!auth.IsGroupMember(request.RequestContext.Authorizer.JWT.Claims, REFEREEGROUP) {
+		isParticipant := false
+		for _, part := range ratingInputParticipants {
+			if part.UserRef.Subject == sub {
+				isParticipant = true
+				break
+			}
+		}
+		if !isParticipant {
+			return nil, http.StatusForbidden, fmt.Errorf("only participants of the game are allowed to submit it")
+		}
+	}
+
 	ratingOutputParticipants := rating.CalculateRatingUpdate(ratingInputParticipants, req.PlacementPoints, MAX_LOSS_NUMBER)
 
 	gameInputParticipants := map[string]put.ParticipantInput{}
-	emailConfirmRequests := []sender.EmailConfirmRequest{}
+	emailConfirmRequests := []outbox.EmailConfirmRequest{}
 
 	for _, part := range ratingOutputParticipants {
 		secret := make([]byte, CONFIRM_SECRET_LENGTH)
@@ -106,7 +211,8 @@ func runAddHandler(dynamoClient *dynamodb.Client, sesClient *sesv2.Client, reque
 		}
 		base64Secret := base64.RawURLEncoding.EncodeToString(secret)
 
-		emailConfirmRequests = append(emailConfirmRequests, sender.EmailConfirmRequest{
+		emailConfirmRequests = append(emailConfirmRequests, outbox.EmailConfirmRequest{
+			Subject:   part.UserRef.Subject,
 			Username:  part.UserRef.Username,
 			Email:     part.UserRef.Email,
 			Secret:    base64Secret,
@@ -115,11 +221,11 @@ func runAddHandler(dynamoClient *dynamodb.Client, sesClient *sesv2.Client, reque
 			EloUpdate: part.RatingUpdate,
 		})
 
-		if _, ok := gameInputParticipants[part.UserRef.Username]; ok {
+		if _, ok := gameInputParticipants[part.UserRef.Subject]; ok {
 			return nil, http.StatusBadRequest, fmt.Errorf("participant: %s found twice", part.UserRef.Username)
 		}
 
-		gameInputParticipants[part.UserRef.Username] = put.ParticipantInput{
+		gameInputParticipants[part.UserRef.Subject] = put.ParticipantInput{
 			Subject:       part.UserRef.Subject,
 			Username:      part.UserRef.Username,
 			Underdog:      part.Underdog,
@@ -130,21 +236,49 @@ func runAddHandler(dynamoClient *dynamodb.Client, sesClient *sesv2.Client, reque
 			EloUpdate:     part.RatingUpdate,
 			Confirmed:     false,
 			ConfirmSecret: base64Secret,
+			MailStatus:    "queued",
 		}
 	}
 
-	expirationTime := time.Now().Add(time.Duration(HOURS_UNTIL_EXPIRED) * time.Hour)
-	gameid, err := put.InsertGame(dynamoClient, ctx, GAMETABLE, gameInputParticipants, int(expirationTime.Unix()))
+	// mails are not sent directly, the jobs are written to the outbox together with the game and delivered by the mailer.
+	gameid := uuid.New().String()
+	mailJobs, err := outbox.BuildConfirmJobs(MAILTEMPLATE, gameid, &outbox.GameInfo{
+		Name:     gameMetadata.Name,
+		Location: gameMetadata.Location,
+		Notes:    gameMetadata.Notes,
+		PlayedAt: gameMetadata.PlayedAt.Format(time.RFC1123),
+	}, emailConfirmRequests)
 	if err != nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("failed to insert game: %v", err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to build confirmation mails: %v", err)
 	}
 
-	if err := sender.SendConfirmMails(sesClient, ctx, MAILSENDER, MAILTEMPLATE, gameid, emailConfirmRequests); err != nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("failed to send at least one confirmation mail: %v", err)
+	expirationTime := time.Now().Add(time.Duration(HOURS_UNTIL_EXPIRED) * time.Hour)
+	err = put.InsertGame(dynamoClient, ctx, GAMETABLE, PARTICIPATIONTABLE, OUTBOXTABLE, IDEMPOTENCYTABLE, gameid, sub, req.QuorumPolicy, &gameMetadata, gameInputParticipants, int(expirationTime.Unix()), mailJobs, idempotencyInput)
+	if errors.Is(err, put.ErrIdempotencyKeyExists) {
+		// a concurrent request with the same idempotency key inserted the game first.
+		record, err := query.FetchByIdempotencyKey(dynamoClient, ctx, IDEMPOTENCYTABLE, idempotencyInput.Key, int(time.Now().Unix()))
+		if err != nil || record == nil {
+			return nil, http.StatusConflict, fmt.Errorf("a request with this idempotency key is already being processed")
+		}
+		return replayIdempotentResponse(record, idempotencyFingerprint)
+	} else if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to insert game: %v", err)
 	}
 
 	return &AddResponse{
-		Message: "successfully added game. ensure all players confirm the game to validate it",
+		Message: ADD_SUCCESS_MESSAGE,
 		GameId:  gameid,
 	}, http.StatusOK, nil
 }
+
+// replayIdempotentResponse rebuilds the original response of a request with the same idempotency key.
+// reusing a key with a different request body is rejected.
+func replayIdempotentResponse(record *query.IdempotencyOutput, fingerprint string) (*AddResponse, int, error) {
+	if record.Fingerprint != fingerprint {
+		return nil, http.StatusUnprocessableEntity, fmt.Errorf("idempotency key was already used with a different request body")
+	}
+	return &AddResponse{
+		Message: record.Message,
+		GameId:  record.GameId,
+	}, http.StatusOK, nil
+}
@@ -10,19 +10,24 @@ import (
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	"github.com/aws/aws-sdk-go-v2/service/sesv2"
 )
 
 var (
-	REGION                = os.Getenv("AWS_REGION")
-	USERTABLE             = os.Getenv("USERTABLE")
-	GAMETABLE             = os.Getenv("GAMETABLE")
-	MAILTEMPLATE          = os.Getenv("MAILTEMPLATE")
-	MAILSENDER            = os.Getenv("MAILSENDER")
-	CONFIRM_SECRET_LENGTH = 20 // default 20
-	HOURS_UNTIL_EXPIRED   = 24 // default 24
-	MAXIMUM_PARTICIPANTS  = 40 // default 40
-	MAX_LOSS_NUMBER       = 40 // default 40
+	REGION                 = os.Getenv("AWS_REGION")
+	USERTABLE              = os.Getenv("USERTABLE")
+	GAMETABLE              = os.Getenv("GAMETABLE")
+	PARTICIPATIONTABLE     = os.Getenv("PARTICIPATIONTABLE")
+	IDEMPOTENCYTABLE       = os.Getenv("IDEMPOTENCYTABLE")
+	MAILTEMPLATE           = os.Getenv("MAILTEMPLATE")
+	OUTBOXTABLE            = os.Getenv("OUTBOXTABLE")
+	ADMINGROUP             = os.Getenv("ADMINGROUP")
+	REFEREEGROUP           = os.Getenv("REFEREEGROUP")
+	CONFIRM_SECRET_LENGTH  = 20 // default 20
+	HOURS_UNTIL_EXPIRED    = 24 // default 24
+	MAXIMUM_PARTICIPANTS   = 40 // default 40
+	MAX_LOSS_NUMBER        = 40 // default 40
+	MAX_PLAYED_AT_AGE_DAYS = 7  // default 7
+	IDEMPOTENCY_TTL_HOURS  = 24 // default 24
 )
 
 func main() {
@@ -37,7 +42,6 @@ func run() error {
 		return fmt.Errorf("failed to load aws config: %v", err)
 	}
 	dynamoClient := dynamodb.NewFromConfig(awsConfig)
-	sesClient := sesv2.NewFromConfig(awsConfig)
 
 	if secretLength, err := strconv.Atoi(os.Getenv("CONFIRM_SECRET_LENGTH")); err == nil {
 		CONFIRM_SECRET_LENGTH = secretLength
@@ -52,6 +56,13 @@ func run() error {
 		MAX_LOSS_NUMBER = maxLossNumber
 	}
 
-	lambda.Start(AddHandler(dynamoClient, sesClient))
+	if maxPlayedAtAgeDays, err := strconv.Atoi(os.Getenv("MAX_PLAYED_AT_AGE_DAYS")); err == nil {
+		MAX_PLAYED_AT_AGE_DAYS = maxPlayedAtAgeDays
+	}
+	if idempotencyTTLHours, err := strconv.Atoi(os.Getenv("IDEMPOTENCY_TTL_HOURS")); err == nil {
+		IDEMPOTENCY_TTL_HOURS = idempotencyTTLHours
+	}
+
+	lambda.Start(AddHandler(dynamoClient))
 	return nil
 }
@@ -0,0 +1,74 @@
+// contains helpers to build the confirmation mail jobs of the outbox.
+// the jobs are written together with the game and delivered by the mailer.
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/megakuul/leaderboard/api/game/add/put"
+)
+
+type EmailConfirmRequest struct {
+	Subject   string
+	Username  string
+	Email     string
+	Secret    string
+	Placement int
+	Points    int
+	EloUpdate int
+}
+
+// GameInfo contains descriptive game data displayed in the mail.
+type GameInfo struct {
+	Name     string
+	Location string
+	Notes    string
+	PlayedAt string
+}
+
+type emailTemplateInput struct {
+	GameId    string `json:"gameid"`
+	Name      string `json:"name"`
+	Location  string `json:"location"`
+	Notes     string `json:"notes"`
+	PlayedAt  string `json:"played_at"`
+	Username  string `json:"username"`
+	Secret    string `json:"secret"`
+	Placement int    `json:"placement"`
+	Points    int    `json:"points"`
+	EloUpdate int    `json:"elo_update"`
+}
+
+// BuildConfirmJobs creates one confirmation mail job per participant.
+// the participant map key is recorded on the job, so that the mailer can update the mail status of the participant.
+func BuildConfirmJobs(mailTemplate, gameId string, gameInfo *GameInfo, emailRequests []EmailConfirmRequest) ([]put.MailJobInput, error) {
+	mailJobs := []put.MailJobInput{}
+	for _, request := range emailRequests {
+		templateInput := emailTemplateInput{
+			GameId:    gameId,
+			Name:      gameInfo.Name,
+			Location:  gameInfo.Location,
+			Notes:     gameInfo.Notes,
+			PlayedAt:  gameInfo.PlayedAt,
+			Username:  request.Username,
+			Secret:    request.Secret,
+			Placement: request.Placement,
+			Points:    request.Points,
+			EloUpdate: request.EloUpdate,
+		}
+		templateInputSerialized, err := json.Marshal(&templateInput)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize mail input")
+		}
+		mailJobs = append(mailJobs, put.MailJobInput{
+			JobId:        fmt.Sprintf("%s#%s", gameId, request.Subject),
+			GameId:       gameId,
+			Participant:  request.Subject,
+			Email:        request.Email,
+			Template:     mailTemplate,
+			TemplateData: string(templateInputSerialized),
+		})
+	}
+	return mailJobs, nil
+}
@@ -5,16 +5,20 @@ package put
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
-	"github.com/google/uuid"
 )
 
+// ErrIdempotencyKeyExists indicates that a game was already inserted with the same idempotency key.
+var ErrIdempotencyKeyExists = errors.New("idempotency key already exists")
+
 type ParticipantInput struct {
 	Subject       string `dynamodbav:"subject"`
 	Username      string `dynamodbav:"username"`
@@ -26,39 +30,165 @@ type ParticipantInput struct {
 	EloUpdate     int    `dynamodbav:"elo_update"`
 	Confirmed     bool   `dynamodbav:"confirmed"`
 	ConfirmSecret string `dynamodbav:"confirm_secret"`
+	MailStatus    string `dynamodbav:"mail_status"`
+}
+
+type MetadataInput struct {
+	Name     string
+	Location string
+	Notes    string
+	PlayedAt time.Time
 }
 
 type GameInput struct {
-	GameId      string                      `dynamodbav:"gameid"`
-	Date        string                      `dynamodbav:"game_date"`
-	ExpiresIn   int                         `dynamodbav:"expires_in"`
-	Readonly    bool                        `dynamodbav:"readonly"`
-	Partcipants map[string]ParticipantInput `dynamodbav:"participants"`
+	GameId       string                      `dynamodbav:"gameid"`
+	Date         string                      `dynamodbav:"game_date"`
+	PlayedAt     string                      `dynamodbav:"played_at"`
+	Name         string                      `dynamodbav:"name,omitempty"`
+	Location     string                      `dynamodbav:"location,omitempty"`
+	Notes        string                      `dynamodbav:"notes,omitempty"`
+	ExpiresIn    int                         `dynamodbav:"expires_in"`
+	Readonly     bool                        `dynamodbav:"readonly"`
+	Pending      string                      `dynamodbav:"pending"`
+	Submitter    string                      `dynamodbav:"submitter"`
+	QuorumPolicy string                      `dynamodbav:"quorum_policy,omitempty"`
+	Partcipants  map[string]ParticipantInput `dynamodbav:"participants"`
+}
+
+type IdempotencyInput struct {
+	Key         string `dynamodbav:"idempotency_key"`
+	Fingerprint string `dynamodbav:"fingerprint"`
+	GameId      string `dynamodbav:"gameid"`
+	Message     string `dynamodbav:"message"`
+	ExpiresIn   int    `dynamodbav:"expires_in"`
+}
+
+// ParticipationInput is an entry of the participant index. It references a game of the participant,
+// participations are sorted by the time the game was played (in utc).
+type ParticipationInput struct {
+	Subject  string `dynamodbav:"subject"`
+	SortKey  string `dynamodbav:"played_at_gameid"`
+	GameId   string `dynamodbav:"gameid"`
+	Username string `dynamodbav:"username"`
+	Date     string `dynamodbav:"game_date"`
 }
 
-func InsertGame(dynamoClient *dynamodb.Client, ctx context.Context, tableName string, participants map[string]ParticipantInput, expirationTime int) (string, error) {
-	gameId := uuid.New().String()
+// MailJobInput is a confirmation mail job of the outbox, it is delivered by the mailer.
+type MailJobInput struct {
+	JobId         string `dynamodbav:"jobid"`
+	GameId        string `dynamodbav:"gameid"`
+	Participant   string `dynamodbav:"participant"`
+	Email         string `dynamodbav:"email"`
+	Template      string `dynamodbav:"template"`
+	TemplateData  string `dynamodbav:"template_data"`
+	Attempts      int    `dynamodbav:"attempts"`
+	NextAttemptAt int    `dynamodbav:"next_attempt_at"`
+	Status        string `dynamodbav:"status"`
+	Queue         string `dynamodbav:"queue"`
+}
 
+// InsertGame inserts a new pending game together with its participations and confirmation mail jobs in one transaction.
+// If an idempotency record is provided, it is written in the same transaction (the gameid is set by InsertGame).
+// If the idempotency key was already used, ErrIdempotencyKeyExists is returned and no game is inserted.
+func InsertGame(dynamoClient *dynamodb.Client, ctx context.Context, tableName, participationTableName, outboxTableName, idempotencyTableName, gameId, submitter, quorumPolicy string, metadata *MetadataInput, participants map[string]ParticipantInput, expirationTime int, mailJobs []MailJobInput, idempotency *IdempotencyInput) error {
+	// the date is derived in the timezone the game was played in.
+	// pending games are indexed in the sparse pending_gsi until they are finalized.
 	gameInput := GameInput{
-		GameId:      gameId,
-		Date:        time.Now().Format("2006-01-02"),
-		Readonly:    false,
-		ExpiresIn:   expirationTime,
-		Partcipants: participants,
+		GameId:       gameId,
+		Date:         metadata.PlayedAt.Format("2006-01-02"),
+		PlayedAt:     metadata.PlayedAt.Format(time.RFC3339),
+		Name:         metadata.Name,
+		Location:     metadata.Location,
+		Notes:        metadata.Notes,
+		Readonly:     false,
+		Pending:      "true",
+		Submitter:    submitter,
+		QuorumPolicy: quorumPolicy,
+		ExpiresIn:    expirationTime,
+		Partcipants:  participants,
 	}
 	gameInputSerialized, err := attributevalue.MarshalMap(&gameInput)
 	if err != nil {
-		return "", fmt.Errorf("failed to serialize put input")
+		return fmt.Errorf("failed to serialize put input")
+	}
+
+	transactItems := []types.TransactWriteItem{
+		{
+			Put: &types.Put{
+				TableName:           aws.String(tableName),
+				Item:                gameInputSerialized,
+				ConditionExpression: aws.String("attribute_not_exists(gameid)"),
+			},
+		},
+	}
+
+	for _, part := range participants {
+		participationSerialized, err := attributevalue.MarshalMap(&ParticipationInput{
+			Subject:  part.Subject,
+			SortKey:  fmt.Sprintf("%s#%s", metadata.PlayedAt.UTC().Format(time.RFC3339), gameId),
+			GameId:   gameId,
+			Username: part.Username,
+			Date:     gameInput.Date,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to serialize participation")
+		}
+		transactItems = append(transactItems, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName: aws.String(participationTableName),
+				Item:      participationSerialized,
+			},
+		})
+	}
+
+	now := int(time.Now().Unix())
+	for _, mailJob := range mailJobs {
+		mailJob.Attempts = 0
+		mailJob.NextAttemptAt = now
+		mailJob.Status = "queued"
+		mailJob.Queue = "pending"
+		mailJobSerialized, err := attributevalue.MarshalMap(&mailJob)
+		if err != nil {
+			return fmt.Errorf("failed to serialize mail job")
+		}
+		transactItems = append(transactItems, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName: aws.String(outboxTableName),
+				Item:      mailJobSerialized,
+			},
+		})
+	}
+
+	if idempotency != nil {
+		idempotency.GameId = gameId
+		idempotencyInputSerialized, err := attributevalue.MarshalMap(idempotency)
+		if err != nil {
+			return fmt.Errorf("failed to serialize idempotency input")
+		}
+		transactItems = append(transactItems, types.TransactWriteItem{
+			// expired records are overwritten, as the ttl process does not remove them immediately.
+			Put: &types.Put{
+				TableName:           aws.String(idempotencyTableName),
+				Item:                idempotencyInputSerialized,
+				ConditionExpression: aws.String("attribute_not_exists(idempotency_key) OR expires_in < :now"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":now": &types.AttributeValueMemberN{Value: strconv.Itoa(now)},
+				},
+			},
+		})
 	}
 
-	_, err = dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName:    aws.String(tableName),
-		Item:         gameInputSerialized,
-		ReturnValues: types.ReturnValueNone,
+	_, err = dynamoClient.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
 	})
 	if err != nil {
-		return "", err
+		var cancelErr *types.TransactionCanceledException
+		if idempotency != nil && errors.As(err, &cancelErr) && len(cancelErr.CancellationReasons) == len(transactItems) &&
+			aws.ToString(cancelErr.CancellationReasons[len(transactItems)-1].Code) == "ConditionalCheckFailed" {
+			return ErrIdempotencyKeyExists
+		}
+		return err
 	}
 
-	return gameId, nil
+	return nil
 }
@@ -0,0 +1,44 @@
+package query
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type IdempotencyOutput struct {
+	Key         string `dynamodbav:"idempotency_key"`
+	Fingerprint string `dynamodbav:"fingerprint"`
+	GameId      string `dynamodbav:"gameid"`
+	Message     string `dynamodbav:"message"`
+	ExpiresIn   int    `dynamodbav:"expires_in"`
+}
+
+// FetchByIdempotencyKey fetches the stored response of a previous request with the same idempotency key.
+// if no record exists (or the record is expired but not yet removed by the ttl process) nil is returned.
+func FetchByIdempotencyKey(dynamoClient *dynamodb.Client, ctx context.Context, tableName, key string, now int) (*IdempotencyOutput, error) {
+	output, err := dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"idempotency_key": &types.AttributeValueMemberS{Value: key},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if output.Item == nil {
+		return nil, nil
+	}
+	var record IdempotencyOutput
+	if err := attributevalue.UnmarshalMap(output.Item, &record); err != nil {
+		return nil, err
+	}
+	if record.ExpiresIn < now {
+		return nil, nil
+	}
+	return &record, nil
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
+// FetchByUsername fetches the user with the specified username.
+// usernames that are shared by multiple users (created before usernames were reserved) are rejected as ambiguous.
 func FetchByUsername(dynamoClient *dynamodb.Client, ctx context.Context, tableName string, username string) (*UserOutput, error) {
 	output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
 		TableName: aws.String(tableName),
@@ -18,7 +20,7 @@ func FetchByUsername(dynamoClient *dynamodb.Client, ctx context.Context, tableNa
 			":username": &types.AttributeValueMemberS{Value: username},
 		},
 		KeyConditionExpression: aws.String("username = :username"),
-		Limit:                  aws.Int32(1),
+		Limit:                  aws.Int32(2),
 	})
 	if err != nil {
 		return nil, err
@@ -31,5 +33,8 @@ func FetchByUsername(dynamoClient *dynamodb.Client, ctx context.Context, tableNa
 	if len(users) < 1 {
 		return nil, fmt.Errorf("user not found")
 	}
+	if len(users) > 1 {
+		return nil, fmt.Errorf("username is ambiguous")
+	}
 	return &users[0], nil
 }
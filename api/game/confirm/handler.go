@@ -2,12 +2,15 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/megakuul/leaderboard/api/game/confirm/query"
+	"github.com/megakuul/leaderboard/api/game/confirm/quorum"
 	"github.com/megakuul/leaderboard/api/game/confirm/update"
 )
 
@@ -45,53 +48,87 @@ func runConfirmHandler(dynamoClient *dynamodb.Client, request *events.APIGateway
 		return "", http.StatusBadRequest, fmt.Errorf("missing query parameter 'code'")
 	}
 
-	game, err := query.FetchById(dynamoClient, ctx, GAMETABLE, gameid)
+	game, err := query.FetchById(dynamoClient, ctx, GAMETABLE, gameid, false)
 	if err != nil {
 		return "", http.StatusNotFound, fmt.Errorf("failed to confirm: %v", err)
 	}
 
 	if game.Readonly {
-		return "", http.StatusBadRequest, fmt.Errorf("the game was already confirmed by all participants and is now readonly")
+		return "", http.StatusBadRequest, fmt.Errorf("the game was already finalized and is now readonly")
 	}
 
-	subject := ""
-	confirmCount := 0
-	for _, part := range game.Participants {
-		if part.Confirmed {
-			confirmCount++
-			continue
-		} else if part.Username == username {
+	// participants are keyed by subject (games submitted before by username), the confirmation link
+	// contains the username the participant had when the game was submitted.
+	participantKey := ""
+	for key, part := range game.Participants {
+		// entries of deleted users are anonymized and can not be confirmed anymore.
+		if !part.Confirmed && !part.Deleted && part.Username == username {
 			if part.ConfirmSecret != code {
 				return "", http.StatusForbidden, fmt.Errorf("invalid confirmation code")
 			}
-			subject = part.Subject
-			confirmCount++
-			continue
+			participantKey = key
+			part.Confirmed = true
+			game.Participants[key] = part
 		}
 	}
-	if subject == "" {
+	if participantKey == "" {
 		return "", http.StatusNotFound, fmt.Errorf("user not found or already confirmed in specified game")
 	}
 
-	if confirmCount != len(game.Participants) {
-		if err := update.UpdateGame(dynamoClient, ctx, GAMETABLE, gameid, username, false); err != nil {
+	quorumPolicy := game.QuorumPolicy
+	if quorumPolicy == "" {
+		quorumPolicy = QUORUM_POLICY
+	}
+
+	if !isQuorumReached(quorumPolicy, game) {
+		if err := update.UpdateGame(dynamoClient, ctx, GAMETABLE, gameid, participantKey, code, false, nil); err != nil {
 			return "", http.StatusBadRequest, fmt.Errorf("failed to update game: %v", err)
 		}
-		return fmt.Sprintf("successfully confirmed game %s", gameid), http.StatusOK, nil
+		// the snapshot may be stale if other participants confirmed concurrently, each of them would see the others
+		// as unconfirmed and the game would never be finalized. therefore the quorum is evaluated again after the write.
+		game, err = query.FetchById(dynamoClient, ctx, GAMETABLE, gameid, true)
+		if err != nil {
+			return "", http.StatusInternalServerError, fmt.Errorf("failed to fetch confirmed game: %v", err)
+		}
+		if game.Readonly || !isQuorumReached(quorumPolicy, game) {
+			return fmt.Sprintf("successfully confirmed game %s", gameid), http.StatusOK, nil
+		}
+	}
+
+	// participants that did not confirm until the quorum was reached are accepted by the quorum.
+	quorumAccepted := []string{}
+	for key, part := range game.Participants {
+		if !part.Confirmed && !part.Deleted && key != participantKey {
+			quorumAccepted = append(quorumAccepted, key)
+		}
+	}
+
+	// the game is finalized before the users are updated,
+	// this ensures the elo is distributed only once if the quorum is reached concurrently.
+	err = update.UpdateGame(dynamoClient, ctx, GAMETABLE, gameid, participantKey, code, true, quorumAccepted)
+	var condErr *types.ConditionalCheckFailedException
+	if errors.As(err, &condErr) {
+		// the condition also fails if a concurrent confirmation finalized the game in the meantime.
+		if finalized, fetchErr := isFinalizedWithSecret(dynamoClient, ctx, gameid, participantKey, code); fetchErr == nil && finalized {
+			return fmt.Sprintf("successfully confirmed game %s", gameid), http.StatusOK, nil
+		}
+		return "", http.StatusBadRequest, fmt.Errorf("failed to update game: %v", err)
+	} else if err != nil {
+		return "", http.StatusBadRequest, fmt.Errorf("failed to update game: %v", err)
 	}
 
 	userUpdateFailure := false
 	for _, part := range game.Participants {
+		// deleted users have no user item anymore, updating them would recreate it.
+		if part.Deleted {
+			continue
+		}
 		err = update.UpdateUser(dynamoClient, ctx, USERTABLE, part.Subject, part.EloUpdate)
 		if err != nil {
 			userUpdateFailure = true
 		}
 	}
 
-	if err := update.UpdateGame(dynamoClient, ctx, GAMETABLE, gameid, username, true); err != nil {
-		return "", http.StatusBadRequest, fmt.Errorf("failed to update game: %v", err)
-	}
-
 	if userUpdateFailure {
 		return "", http.StatusInternalServerError, fmt.Errorf(
 			"game update successful, but one or more user updates failed. If points are missing, please contact an administrator")
@@ -99,3 +136,30 @@ func runConfirmHandler(dynamoClient *dynamodb.Client, request *events.APIGateway
 		return fmt.Sprintf("successfully confirmed game %s", gameid), http.StatusOK, nil
 	}
 }
+
+// isQuorumReached evaluates the quorum policy on the participants of the game.
+// anonymized entries can never confirm, they would block the quorum of the remaining participants.
+func isQuorumReached(quorumPolicy string, game *query.GameOutput) bool {
+	quorumParticipants := []quorum.Participant{}
+	for _, part := range game.Participants {
+		if part.Deleted {
+			continue
+		}
+		quorumParticipants = append(quorumParticipants, quorum.Participant{
+			Subject:   part.Subject,
+			Team:      part.Team,
+			Confirmed: part.Confirmed,
+		})
+	}
+	return quorum.IsReached(quorumPolicy, game.Submitter, quorumParticipants)
+}
+
+// isFinalizedWithSecret checks if the game was finalized and the participant was not changed by an edit (secrets are rotated on edits).
+func isFinalizedWithSecret(dynamoClient *dynamodb.Client, ctx context.Context, gameid, participantKey, code string) (bool, error) {
+	game, err := query.FetchById(dynamoClient, ctx, GAMETABLE, gameid, true)
+	if err != nil {
+		return false, err
+	}
+	part, ok := game.Participants[participantKey]
+	return game.Readonly && ok && part.ConfirmSecret == code, nil
+}
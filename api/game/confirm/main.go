@@ -9,12 +9,15 @@ import (
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/megakuul/leaderboard/api/game/confirm/quorum"
 )
 
 var (
 	REGION    = os.Getenv("AWS_REGION")
 	USERTABLE = os.Getenv("USERTABLE")
 	GAMETABLE = os.Getenv("GAMETABLE")
+	// quorum policy applied to games that do not specify their own policy.
+	QUORUM_POLICY = "all" // default "all"
 )
 
 func main() {
@@ -30,6 +33,13 @@ func run() error {
 	}
 	dynamoClient := dynamodb.NewFromConfig(awsConfig)
 
+	if quorumPolicy := os.Getenv("QUORUM_POLICY"); quorumPolicy != "" {
+		if !quorum.IsValid(quorumPolicy) {
+			return fmt.Errorf("invalid quorum policy: %s", quorumPolicy)
+		}
+		QUORUM_POLICY = quorumPolicy
+	}
+
 	lambda.Start(ConfirmHandler(dynamoClient))
 	return nil
 }
@@ -10,7 +10,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
-func FetchById(dynamoClient *dynamodb.Client, ctx context.Context, tableName string, gameid string) (*GameOutput, error) {
+// FetchById fetches the game by its id, consistentRead ensures that writes of concurrent confirmations are visible.
+func FetchById(dynamoClient *dynamodb.Client, ctx context.Context, tableName string, gameid string, consistentRead bool) (*GameOutput, error) {
 	output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
 		TableName: aws.String(tableName),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
@@ -18,6 +19,7 @@ func FetchById(dynamoClient *dynamodb.Client, ctx context.Context, tableName str
 		},
 		KeyConditionExpression: aws.String("gameid = :gameid"),
 		Limit:                  aws.Int32(1),
+		ConsistentRead:         aws.Bool(consistentRead),
 	})
 	if err != nil {
 		return nil, err
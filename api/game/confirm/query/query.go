@@ -6,13 +6,17 @@ package query
 type ParticipantOutput struct {
 	Subject       string `dynamodbav:"subject"`
 	Username      string `dynamodbav:"username"`
+	Team          int    `dynamodbav:"team"`
 	EloUpdate     int    `dynamodbav:"elo_update"`
 	Confirmed     bool   `dynamodbav:"confirmed"`
 	ConfirmSecret string `dynamodbav:"confirm_secret"`
+	Deleted       bool   `dynamodbav:"deleted"`
 }
 
 type GameOutput struct {
 	GameId       string                       `dynamodbav:"gameid"`
 	Readonly     bool                         `dynamodbav:"readonly"`
+	Submitter    string                       `dynamodbav:"submitter"`
+	QuorumPolicy string                       `dynamodbav:"quorum_policy"`
 	Participants map[string]ParticipantOutput `dynamodbav:"participants"`
 }
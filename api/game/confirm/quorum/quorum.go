@@ -0,0 +1,90 @@
+// contains the quorum policies that decide
+// when a game has enough confirmations to be finalized.
+// submitted policies are validated by copies of IsValid (api/game/add/quorum and api/game/import/quorum),
+// new policies must be added there as well.
+package quorum
+
+const (
+	// every participant must confirm the game.
+	POLICY_ALL = "all"
+	// more than half of the participants must confirm the game.
+	POLICY_MAJORITY = "majority"
+	// at least one participant of every team must confirm the game.
+	POLICY_TEAM = "team"
+	// the submitter and at least one participant of another team must confirm the game.
+	POLICY_OPPONENT = "opponent"
+)
+
+type Participant struct {
+	Subject   string
+	Team      int
+	Confirmed bool
+}
+
+// IsValid checks if the provided policy is a known quorum policy.
+func IsValid(policy string) bool {
+	switch policy {
+	case POLICY_ALL, POLICY_MAJORITY, POLICY_TEAM, POLICY_OPPONENT:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsReached checks if the confirmed participants satisfy the specified policy.
+// unknown policies are evaluated like POLICY_ALL.
+func IsReached(policy, submitter string, participants []Participant) bool {
+	switch policy {
+	case POLICY_MAJORITY:
+		confirmCount := 0
+		for _, part := range participants {
+			if part.Confirmed {
+				confirmCount++
+			}
+		}
+		return confirmCount*2 > len(participants)
+
+	case POLICY_TEAM:
+		teamConfirmed := map[int]bool{}
+		for _, part := range participants {
+			teamConfirmed[part.Team] = teamConfirmed[part.Team] || part.Confirmed
+		}
+		for _, confirmed := range teamConfirmed {
+			if !confirmed {
+				return false
+			}
+		}
+		return true
+
+	case POLICY_OPPONENT:
+		var submitterRef *Participant = nil
+		for i, part := range participants {
+			if part.Subject == submitter {
+				submitterRef = &participants[i]
+				break
+			}
+		}
+		// if the submitter is not part of the game, there is no opponent
+		// that could be determined; therefore all participants must confirm.
+		if submitterRef == nil {
+			return IsReached(POLICY_ALL, submitter, participants)
+		}
+		if !submitterRef.Confirmed {
+			return false
+		}
+		for _, part := range participants {
+			if part.Confirmed && part.Team != submitterRef.Team {
+				return true
+			}
+		}
+		return false
+
+	default:
+		for _, part := range participants {
+			if !part.Confirmed {
+				return false
+			}
+		}
+		return true
+	}
+}
@@ -0,0 +1,158 @@
+package quorum
+
+import "testing"
+
+func TestIsValid(t *testing.T) {
+	tests := []struct {
+		policy string
+		want   bool
+	}{
+		{POLICY_ALL, true},
+		{POLICY_MAJORITY, true},
+		{POLICY_TEAM, true},
+		{POLICY_OPPONENT, true},
+		{"", false},
+		{"Majority", false},
+		{"unknown", false},
+	}
+	for _, tt := range tests {
+		if got := IsValid(tt.policy); got != tt.want {
+			t.Errorf("IsValid(%q) = %v, want %v", tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestIsReached(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       string
+		submitter    string
+		participants []Participant
+		want         bool
+	}{
+		{
+			name:   "all confirmed",
+			policy: POLICY_ALL,
+			participants: []Participant{
+				{Subject: "a", Team: 1, Confirmed: true},
+				{Subject: "b", Team: 2, Confirmed: true},
+			},
+			want: true,
+		},
+		{
+			name:   "all with one unconfirmed",
+			policy: POLICY_ALL,
+			participants: []Participant{
+				{Subject: "a", Team: 1, Confirmed: true},
+				{Subject: "b", Team: 2, Confirmed: false},
+			},
+			want: false,
+		},
+		{
+			name:         "all without participants",
+			policy:       POLICY_ALL,
+			participants: []Participant{},
+			want:         true,
+		},
+		{
+			name:   "unknown policy is evaluated like all",
+			policy: "unknown",
+			participants: []Participant{
+				{Subject: "a", Team: 1, Confirmed: true},
+				{Subject: "b", Team: 2, Confirmed: false},
+			},
+			want: false,
+		},
+		{
+			name:   "majority with more than half",
+			policy: POLICY_MAJORITY,
+			participants: []Participant{
+				{Subject: "a", Team: 1, Confirmed: true},
+				{Subject: "b", Team: 1, Confirmed: true},
+				{Subject: "c", Team: 2, Confirmed: false},
+			},
+			want: true,
+		},
+		{
+			name:   "majority with exactly half",
+			policy: POLICY_MAJORITY,
+			participants: []Participant{
+				{Subject: "a", Team: 1, Confirmed: true},
+				{Subject: "b", Team: 1, Confirmed: true},
+				{Subject: "c", Team: 2, Confirmed: false},
+				{Subject: "d", Team: 2, Confirmed: false},
+			},
+			want: false,
+		},
+		{
+			name:   "team with every team confirmed",
+			policy: POLICY_TEAM,
+			participants: []Participant{
+				{Subject: "a", Team: 1, Confirmed: true},
+				{Subject: "b", Team: 1, Confirmed: false},
+				{Subject: "c", Team: 2, Confirmed: false},
+				{Subject: "d", Team: 2, Confirmed: true},
+			},
+			want: true,
+		},
+		{
+			name:   "team with one team unconfirmed",
+			policy: POLICY_TEAM,
+			participants: []Participant{
+				{Subject: "a", Team: 1, Confirmed: true},
+				{Subject: "b", Team: 1, Confirmed: true},
+				{Subject: "c", Team: 2, Confirmed: false},
+			},
+			want: false,
+		},
+		{
+			name:      "opponent with submitter and opponent confirmed",
+			policy:    POLICY_OPPONENT,
+			submitter: "a",
+			participants: []Participant{
+				{Subject: "a", Team: 1, Confirmed: true},
+				{Subject: "b", Team: 1, Confirmed: false},
+				{Subject: "c", Team: 2, Confirmed: true},
+			},
+			want: true,
+		},
+		{
+			name:      "opponent with only a teammate confirmed",
+			policy:    POLICY_OPPONENT,
+			submitter: "a",
+			participants: []Participant{
+				{Subject: "a", Team: 1, Confirmed: true},
+				{Subject: "b", Team: 1, Confirmed: true},
+				{Subject: "c", Team: 2, Confirmed: false},
+			},
+			want: false,
+		},
+		{
+			name:      "opponent without submitter confirmation",
+			policy:    POLICY_OPPONENT,
+			submitter: "a",
+			participants: []Participant{
+				{Subject: "a", Team: 1, Confirmed: false},
+				{Subject: "c", Team: 2, Confirmed: true},
+			},
+			want: false,
+		},
+		{
+			name:      "opponent with submitter outside of the game requires all",
+			policy:    POLICY_OPPONENT,
+			submitter: "referee",
+			participants: []Participant{
+				{Subject: "a", Team: 1, Confirmed: true},
+				{Subject: "c", Team: 2, Confirmed: false},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsReached(tt.policy, tt.submitter, tt.participants); got != tt.want {
+				t.Errorf("IsReached() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
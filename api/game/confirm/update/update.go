@@ -5,6 +5,7 @@ package update
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -12,23 +13,45 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
-func UpdateGame(dynamoClient *dynamodb.Client, ctx context.Context, tableName, gameid, username string, setReadonly bool) error {
+// UpdateGame confirms the game for the specified participant (participant map key).
+// if setReadonly is set, the game is finalized and all quorumAccepted participants are marked as accepted by quorum.
+func UpdateGame(dynamoClient *dynamodb.Client, ctx context.Context, tableName, gameid, participant, confirmSecret string, setReadonly bool, quorumAccepted []string) error {
 	expressionAttributeNames := map[string]string{
-		"#participants": "participants",
-		"#username":     username,
-		"#confirmed":    "confirmed",
+		"#participants":   "participants",
+		"#participant":    participant,
+		"#confirmed":      "confirmed",
+		"#confirm_secret": "confirm_secret",
 	}
 	expressionAttributeValues := map[string]types.AttributeValue{
-		":confirmed": &types.AttributeValueMemberBOOL{Value: true},
+		":confirmed":      &types.AttributeValueMemberBOOL{Value: true},
+		":confirm_secret": &types.AttributeValueMemberS{Value: confirmSecret},
 	}
 	var updateExpression string
+	// prevent it to upsert if not existent and ensure the game was not edited in the meantime (secrets are rotated on edits)
+	conditionExpression := "attribute_exists(gameid) AND #participants.#participant.#confirm_secret = :confirm_secret"
 	if setReadonly {
+		// prevent concurrent confirmations from finalizing the game twice
+		conditionExpression += " AND #readonly = :not_readonly"
+		expressionAttributeValues[":not_readonly"] = &types.AttributeValueMemberBOOL{Value: false}
 		expressionAttributeNames["#readonly"] = "readonly"
 		expressionAttributeNames["#expires_in"] = "expires_in"
+		expressionAttributeNames["#pending"] = "pending"
 		expressionAttributeValues[":readonly"] = &types.AttributeValueMemberBOOL{Value: true}
-		updateExpression = "SET #readonly = :readonly, #participants.#username.#confirmed = :confirmed  REMOVE #expires_in"
+		updateExpression = "SET #readonly = :readonly, #participants.#participant.#confirmed = :confirmed"
+		if len(quorumAccepted) > 0 {
+			// dynamodb rejects unused expression attributes, therefore they are only set if required.
+			expressionAttributeNames["#accepted_by_quorum"] = "accepted_by_quorum"
+			expressionAttributeValues[":accepted_by_quorum"] = &types.AttributeValueMemberBOOL{Value: true}
+		}
+		for i, acceptedParticipant := range quorumAccepted {
+			nameKey := fmt.Sprintf("#quorum_participant_%d", i)
+			expressionAttributeNames[nameKey] = acceptedParticipant
+			updateExpression += fmt.Sprintf(", #participants.%s.#accepted_by_quorum = :accepted_by_quorum", nameKey)
+		}
+		// removing pending drops the game from the sparse pending_gsi (no more reminders).
+		updateExpression += " REMOVE #expires_in, #pending"
 	} else {
-		updateExpression = "SET #participants.#username.#confirmed = :confirmed"
+		updateExpression = "SET #participants.#participant.#confirmed = :confirmed"
 	}
 
 	_, err := dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
@@ -36,7 +59,7 @@ func UpdateGame(dynamoClient *dynamodb.Client, ctx context.Context, tableName, g
 		Key: map[string]types.AttributeValue{
 			"gameid": &types.AttributeValueMemberS{Value: gameid},
 		},
-		ConditionExpression:       aws.String("attribute_exists(gameid)"), // prevent it to upsert if not existent
+		ConditionExpression:       aws.String(conditionExpression),
 		ExpressionAttributeNames:  expressionAttributeNames,
 		ExpressionAttributeValues: expressionAttributeValues,
 		UpdateExpression:          aws.String(updateExpression),
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/megakuul/leaderboard/api/game/edit/auth"
+	"github.com/megakuul/leaderboard/api/game/edit/outbox"
+	"github.com/megakuul/leaderboard/api/game/edit/query"
+	"github.com/megakuul/leaderboard/api/game/edit/rating"
+	"github.com/megakuul/leaderboard/api/game/edit/update"
+)
+
+type Participant struct {
+	Username  string `json:"username"`
+	Team      int    `json:"team"`
+	Points    int    `json:"points"`
+	Placement int    `json:"placement"`
+}
+
+type EditRequest struct {
+	GameId          string        `json:"gameid"`
+	PlacementPoints int           `json:"placement_points"`
+	Participants    []Participant `json:"participants"`
+}
+
+type EditResponse struct {
+	Message string `json:"message"`
+	GameId  string `json:"gameid"`
+}
+
+func EditHandler(dynamoClient *dynamodb.Client) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		response, code, err := runEditHandler(dynamoClient, &request, ctx)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: code,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       err.Error(),
+			}, nil
+		}
+		serializedResponse, err := json.Marshal(&response)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: http.StatusInternalServerError,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       "failed to serialize response",
+			}, nil
+		}
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode: code,
+			Headers:    map[string]string{"Content-Type": "application/json"},
+			Body:       string(serializedResponse),
+		}, nil
+	}
+}
+
+func runEditHandler(dynamoClient *dynamodb.Client, request *events.APIGatewayV2HTTPRequest, ctx context.Context) (*EditResponse, int, error) {
+	sub := request.RequestContext.Authorizer.JWT.Claims["sub"]
+	if sub == "" {
+		return nil, http.StatusUnprocessableEntity, fmt.Errorf("invalid sub claim in the ID token")
+	}
+
+	var req EditRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to deserialize request: invalid body")
+	}
+
+	if req.GameId == "" {
+		return nil, http.StatusBadRequest, fmt.Errorf("missing gameid")
+	}
+
+	game, err := query.FetchById(dynamoClient, ctx, GAMETABLE, req.GameId)
+	if err != nil {
+		return nil, http.StatusNotFound, fmt.Errorf("failed to edit: %v", err)
+	}
+
+	if game.Submitter != sub {
+		return nil, http.StatusForbidden, fmt.Errorf("only the submitter of the game is allowed to edit it")
+	}
+
+	if game.Readonly {
+		return nil, http.StatusBadRequest, fmt.Errorf("the game was already finalized and is now readonly")
+	}
+
+	if len(req.Participants) < 2 {
+		return nil, http.StatusBadRequest, fmt.Errorf("minimum number of participants is 2")
+	}
+
+	if len(req.Participants) > MAXIMUM_PARTICIPANTS {
+		return nil, http.StatusBadRequest, fmt.Errorf("maximum number of participants is %d", MAXIMUM_PARTICIPANTS)
+	}
+
+	ratingInputParticipants := []rating.ParticipantInput{}
+	for _, part := range req.Participants {
+		user, err := query.FetchByUsername(dynamoClient, ctx, USERTABLE, part.Username)
+		if err != nil {
+			return nil, http.StatusNotFound, fmt.Errorf("failed to lookup %s: %v", part.Username, err)
+		}
+		if user.Disabled {
+			return nil, http.StatusNotFound, fmt.Errorf("failed to lookup %s: user is disabled", part.Username)
+		}
+		ratingInputParticipants = append(ratingInputParticipants, rating.ParticipantInput{
+			UserRef:   user,
+			Team:      part.Team,
+			Rating:    user.Elo,
+			Points:    part.Points,
+			Placement: part.Placement,
+		})
+	}
+
+	// only participants are allowed to submit games, admins and referees can submit on behalf of others.
+	if !auth.IsGroupMember(request.RequestContext.Authorizer.JWT.Claims, ADMINGROUP) &&
+		!auth.IsGroupMember(request.RequestContext.Authorizer.JWT.Claims, REFEREEGROUP) {
+		isParticipant := false
+		for _, part := range ratingInputParticipants {
+			if part.UserRef.Subject == sub {
+				isParticipant = true
+				break
+			}
+		}
+		if !isParticipant {
+			return nil, http.StatusForbidden, fmt.Errorf("only participants of the game are allowed to submit it")
+		}
+	}
+
+	ratingOutputParticipants := rating.CalculateRatingUpdate(ratingInputParticipants, req.PlacementPoints, MAX_LOSS_NUMBER)
+
+	gameInputParticipants := map[string]update.ParticipantInput{}
+	emailConfirmRequests := []outbox.EmailConfirmRequest{}
+
+	for _, part := range ratingOutputParticipants {
+		// secrets are rotated, confirmation links of the previous version are invalidated this way.
+		secret := make([]byte, CONFIRM_SECRET_LENGTH)
+		_, err := rand.Read(secret)
+		if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to generate confirmation secret")
+		}
+		base64Secret := base64.RawURLEncoding.EncodeToString(secret)
+
+		emailConfirmRequests = append(emailConfirmRequests, outbox.EmailConfirmRequest{
+			Subject:   part.UserRef.Subject,
+			Username:  part.UserRef.Username,
+			Email:     part.UserRef.Email,
+			Secret:    base64Secret,
+			Placement: part.Placement,
+			Points:    part.Points,
+			EloUpdate: part.RatingUpdate,
+		})
+
+		if _, ok := gameInputParticipants[part.UserRef.Subject]; ok {
+			return nil, http.StatusBadRequest, fmt.Errorf("participant: %s found twice", part.UserRef.Username)
+		}
+
+		gameInputParticipants[part.UserRef.Subject] = update.ParticipantInput{
+			Subject:       part.UserRef.Subject,
+			Username:      part.UserRef.Username,
+			Underdog:      part.Underdog,
+			Team:          part.Team,
+			Placement:     part.Placement,
+			Points:        part.Points,
+			Elo:           part.Rating,
+			EloUpdate:     part.RatingUpdate,
+			Confirmed:     false,
+			ConfirmSecret: base64Secret,
+			MailStatus:    "queued",
+		}
+	}
+
+	previousParticipants := map[string]update.HistoryParticipantInput{}
+	for key, part := range game.Participants {
+		previousParticipants[key] = update.HistoryParticipantInput{
+			Subject:   part.Subject,
+			Username:  part.Username,
+			Underdog:  part.Underdog,
+			Team:      part.Team,
+			Placement: part.Placement,
+			Points:    part.Points,
+			Elo:       part.Elo,
+			EloUpdate: part.EloUpdate,
+			Confirmed: part.Confirmed,
+		}
+	}
+
+	// games submitted before played_at was introduced are not indexed in the participations.
+	playedAt := game.PlayedAt
+	parsedPlayedAt, err := time.Parse(time.RFC3339, game.PlayedAt)
+	if err == nil {
+		playedAt = parsedPlayedAt.Format(time.RFC1123)
+	}
+
+	mailJobs, err := outbox.BuildConfirmJobs(MAILTEMPLATE, game.GameId, &outbox.GameInfo{
+		Name:     game.Name,
+		Location: game.Location,
+		Notes:    game.Notes,
+		PlayedAt: playedAt,
+	}, emailConfirmRequests)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to build confirmation mails: %v", err)
+	}
+
+	expirationTime := time.Now().Add(time.Duration(HOURS_UNTIL_EXPIRED) * time.Hour)
+	err = update.EditGame(dynamoClient, ctx, GAMETABLE, PARTICIPATIONTABLE, OUTBOXTABLE, game.GameId, game.Date, sub, parsedPlayedAt, gameInputParticipants, previousParticipants, int(expirationTime.Unix()), mailJobs)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to edit game: %v", err)
+	}
+
+	return &EditResponse{
+		Message: "successfully edited game. ensure all players confirm the corrected game to validate it",
+		GameId:  game.GameId,
+	}, http.StatusOK, nil
+}
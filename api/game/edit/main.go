@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+var (
+	REGION                = os.Getenv("AWS_REGION")
+	USERTABLE             = os.Getenv("USERTABLE")
+	GAMETABLE             = os.Getenv("GAMETABLE")
+	PARTICIPATIONTABLE    = os.Getenv("PARTICIPATIONTABLE")
+	MAILTEMPLATE          = os.Getenv("MAILTEMPLATE")
+	OUTBOXTABLE           = os.Getenv("OUTBOXTABLE")
+	ADMINGROUP            = os.Getenv("ADMINGROUP")
+	REFEREEGROUP          = os.Getenv("REFEREEGROUP")
+	CONFIRM_SECRET_LENGTH = 20 // default 20
+	HOURS_UNTIL_EXPIRED   = 24 // default 24
+	MAXIMUM_PARTICIPANTS  = 40 // default 40
+	MAX_LOSS_NUMBER       = 40 // default 40
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatalf("ERROR INITIALIZATION: %v\n", err)
+	}
+}
+
+func run() error {
+	awsConfig, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(REGION))
+	if err != nil {
+		return fmt.Errorf("failed to load aws config: %v", err)
+	}
+	dynamoClient := dynamodb.NewFromConfig(awsConfig)
+
+	if secretLength, err := strconv.Atoi(os.Getenv("CONFIRM_SECRET_LENGTH")); err == nil {
+		CONFIRM_SECRET_LENGTH = secretLength
+	}
+	if hoursUntilExpired, err := strconv.Atoi(os.Getenv("HOURS_UNTIL_EXPIRED")); err == nil {
+		HOURS_UNTIL_EXPIRED = hoursUntilExpired
+	}
+	if maximumParticipants, err := strconv.Atoi(os.Getenv("MAXIMUM_PARTICIPANTS")); err == nil {
+		MAXIMUM_PARTICIPANTS = maximumParticipants
+	}
+	if maxLossNumber, err := strconv.Atoi(os.Getenv("MAX_LOSS_NUMBER")); err == nil {
+		MAX_LOSS_NUMBER = maxLossNumber
+	}
+
+	lambda.Start(EditHandler(dynamoClient))
+	return nil
+}
@@ -0,0 +1,76 @@
+// contains helpers to build the corrected confirmation mail jobs of the outbox.
+// the jobs are written together with the edited game and delivered by the mailer.
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/megakuul/leaderboard/api/game/edit/update"
+)
+
+type EmailConfirmRequest struct {
+	Subject   string
+	Username  string
+	Email     string
+	Secret    string
+	Placement int
+	Points    int
+	EloUpdate int
+}
+
+// GameInfo contains descriptive game data displayed in the mail.
+type GameInfo struct {
+	Name     string
+	Location string
+	Notes    string
+	PlayedAt string
+}
+
+type emailTemplateInput struct {
+	GameId    string `json:"gameid"`
+	Name      string `json:"name"`
+	Location  string `json:"location"`
+	Notes     string `json:"notes"`
+	PlayedAt  string `json:"played_at"`
+	Corrected bool   `json:"corrected"`
+	Username  string `json:"username"`
+	Secret    string `json:"secret"`
+	Placement int    `json:"placement"`
+	Points    int    `json:"points"`
+	EloUpdate int    `json:"elo_update"`
+}
+
+// BuildConfirmJobs creates one corrected confirmation mail job per participant.
+// the jobs use the same jobid as the initial confirmation, so that a pending job with a rotated secret is replaced.
+func BuildConfirmJobs(mailTemplate, gameId string, gameInfo *GameInfo, emailRequests []EmailConfirmRequest) ([]update.MailJobInput, error) {
+	mailJobs := []update.MailJobInput{}
+	for _, request := range emailRequests {
+		templateInput := emailTemplateInput{
+			GameId:    gameId,
+			Name:      gameInfo.Name,
+			Location:  gameInfo.Location,
+			Notes:     gameInfo.Notes,
+			PlayedAt:  gameInfo.PlayedAt,
+			Corrected: true,
+			Username:  request.Username,
+			Secret:    request.Secret,
+			Placement: request.Placement,
+			Points:    request.Points,
+			EloUpdate: request.EloUpdate,
+		}
+		templateInputSerialized, err := json.Marshal(&templateInput)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize mail input")
+		}
+		mailJobs = append(mailJobs, update.MailJobInput{
+			JobId:        fmt.Sprintf("%s#%s", gameId, request.Subject),
+			GameId:       gameId,
+			Participant:  request.Subject,
+			Email:        request.Email,
+			Template:     mailTemplate,
+			TemplateData: string(templateInputSerialized),
+		})
+	}
+	return mailJobs, nil
+}
@@ -0,0 +1,36 @@
+// contains wrappers for database query functions.
+// main purpose is to abstract some boilerplate code
+// away from the handler.
+package query
+
+type UserOutput struct {
+	Subject  string `dynamodbav:"subject"`
+	Disabled bool   `dynamodbav:"disabled"`
+	Username string `dynamodbav:"username"`
+	Elo      int    `dynamodbav:"elo"`
+	Email    string `dynamodbav:"email"`
+}
+
+type ParticipantOutput struct {
+	Subject   string `dynamodbav:"subject"`
+	Username  string `dynamodbav:"username"`
+	Underdog  bool   `dynamodbav:"underdog"`
+	Team      int    `dynamodbav:"team"`
+	Placement int    `dynamodbav:"placement"`
+	Points    int    `dynamodbav:"points"`
+	Elo       int    `dynamodbav:"elo"`
+	EloUpdate int    `dynamodbav:"elo_update"`
+	Confirmed bool   `dynamodbav:"confirmed"`
+}
+
+type GameOutput struct {
+	GameId       string                       `dynamodbav:"gameid"`
+	Readonly     bool                         `dynamodbav:"readonly"`
+	Submitter    string                       `dynamodbav:"submitter"`
+	Date         string                       `dynamodbav:"game_date"`
+	PlayedAt     string                       `dynamodbav:"played_at"`
+	Name         string                       `dynamodbav:"name"`
+	Location     string                       `dynamodbav:"location"`
+	Notes        string                       `dynamodbav:"notes"`
+	Participants map[string]ParticipantOutput `dynamodbav:"participants"`
+}
@@ -0,0 +1,209 @@
+// contains wrappers for database update functions.
+// main purpose is to abstract some boilerplate code
+// away from the handler.
+package update
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type ParticipantInput struct {
+	Subject       string `dynamodbav:"subject"`
+	Username      string `dynamodbav:"username"`
+	Underdog      bool   `dynamodbav:"underdog"`
+	Team          int    `dynamodbav:"team"`
+	Placement     int    `dynamodbav:"placement"`
+	Points        int    `dynamodbav:"points"`
+	Elo           int    `dynamodbav:"elo"`
+	EloUpdate     int    `dynamodbav:"elo_update"`
+	Confirmed     bool   `dynamodbav:"confirmed"`
+	ConfirmSecret string `dynamodbav:"confirm_secret"`
+	MailStatus    string `dynamodbav:"mail_status"`
+}
+
+type HistoryParticipantInput struct {
+	Subject   string `dynamodbav:"subject"`
+	Username  string `dynamodbav:"username"`
+	Underdog  bool   `dynamodbav:"underdog"`
+	Team      int    `dynamodbav:"team"`
+	Placement int    `dynamodbav:"placement"`
+	Points    int    `dynamodbav:"points"`
+	Elo       int    `dynamodbav:"elo"`
+	EloUpdate int    `dynamodbav:"elo_update"`
+	Confirmed bool   `dynamodbav:"confirmed"`
+}
+
+// HistoryInput represents the version of the game before it was edited.
+type HistoryInput struct {
+	EditedAt     int                                `dynamodbav:"edited_at"`
+	Participants map[string]HistoryParticipantInput `dynamodbav:"participants"`
+}
+
+// ParticipationInput is an entry of the participant index. It references a game of the participant,
+// participations are sorted by the time the game was played (in utc).
+type ParticipationInput struct {
+	Subject  string `dynamodbav:"subject"`
+	SortKey  string `dynamodbav:"played_at_gameid"`
+	GameId   string `dynamodbav:"gameid"`
+	Username string `dynamodbav:"username"`
+	Date     string `dynamodbav:"game_date"`
+}
+
+// MailJobInput is a confirmation mail job of the outbox, it is delivered by the mailer.
+type MailJobInput struct {
+	JobId         string `dynamodbav:"jobid"`
+	GameId        string `dynamodbav:"gameid"`
+	Participant   string `dynamodbav:"participant"`
+	Email         string `dynamodbav:"email"`
+	Template      string `dynamodbav:"template"`
+	TemplateData  string `dynamodbav:"template_data"`
+	Attempts      int    `dynamodbav:"attempts"`
+	NextAttemptAt int    `dynamodbav:"next_attempt_at"`
+	Status        string `dynamodbav:"status"`
+	Queue         string `dynamodbav:"queue"`
+}
+
+// EditGame replaces the participants of a pending game and appends the previous version to the game history.
+// the update is only performed if the game is not readonly and was submitted by the specified submitter.
+// participations of removed participants are deleted and participations of added participants are inserted
+// in the same transaction (skipped for games without played_at, as they are not indexed).
+// the corrected confirmation mail jobs are enqueued in the same transaction, jobs of removed participants are dropped.
+func EditGame(dynamoClient *dynamodb.Client, ctx context.Context, tableName, participationTableName, outboxTableName, gameid, gameDate, submitter string, playedAt time.Time, participants map[string]ParticipantInput, previousParticipants map[string]HistoryParticipantInput, expirationTime int, mailJobs []MailJobInput) error {
+	participantsSerialized, err := attributevalue.Marshal(participants)
+	if err != nil {
+		return fmt.Errorf("failed to serialize participants")
+	}
+
+	editTime := int(time.Now().Unix())
+	historySerialized, err := attributevalue.Marshal([]HistoryInput{{
+		EditedAt:     editTime,
+		Participants: previousParticipants,
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to serialize history")
+	}
+
+	transactItems := []types.TransactWriteItem{{
+		Update: &types.Update{
+			TableName: aws.String(tableName),
+			Key: map[string]types.AttributeValue{
+				"gameid": &types.AttributeValueMemberS{Value: gameid},
+			},
+			ExpressionAttributeNames: map[string]string{
+				"#participants": "participants",
+				"#history":      "history",
+				"#edited_at":    "edited_at",
+				"#expires_in":   "expires_in",
+				"#readonly":     "readonly",
+				"#submitter":    "submitter",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":participants": participantsSerialized,
+				":history":      historySerialized,
+				":empty_list":   &types.AttributeValueMemberL{Value: []types.AttributeValue{}},
+				":edited_at":    &types.AttributeValueMemberN{Value: strconv.Itoa(editTime)},
+				":expires_in":   &types.AttributeValueMemberN{Value: strconv.Itoa(expirationTime)},
+				":readonly":     &types.AttributeValueMemberBOOL{Value: false},
+				":submitter":    &types.AttributeValueMemberS{Value: submitter},
+			},
+			// prevent edits on finalized games or games of other submitters
+			ConditionExpression: aws.String("attribute_exists(gameid) AND #readonly = :readonly AND #submitter = :submitter"),
+			UpdateExpression: aws.String(
+				"SET #participants = :participants, #history = list_append(if_not_exists(#history, :empty_list), :history), #edited_at = :edited_at, #expires_in = :expires_in"),
+		},
+	}}
+
+	if !playedAt.IsZero() {
+		sortKey := fmt.Sprintf("%s#%s", playedAt.UTC().Format(time.RFC3339), gameid)
+		newSubjects := map[string]bool{}
+		for _, part := range participants {
+			newSubjects[part.Subject] = true
+		}
+		previousSubjects := map[string]bool{}
+		for _, part := range previousParticipants {
+			previousSubjects[part.Subject] = true
+			if newSubjects[part.Subject] {
+				continue
+			}
+			transactItems = append(transactItems, types.TransactWriteItem{
+				Delete: &types.Delete{
+					TableName: aws.String(participationTableName),
+					Key: map[string]types.AttributeValue{
+						"subject":          &types.AttributeValueMemberS{Value: part.Subject},
+						"played_at_gameid": &types.AttributeValueMemberS{Value: sortKey},
+					},
+				},
+			})
+		}
+		for _, part := range participants {
+			if previousSubjects[part.Subject] {
+				continue
+			}
+			participationSerialized, err := attributevalue.MarshalMap(&ParticipationInput{
+				Subject:  part.Subject,
+				SortKey:  sortKey,
+				GameId:   gameid,
+				Username: part.Username,
+				Date:     gameDate,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to serialize participation")
+			}
+			transactItems = append(transactItems, types.TransactWriteItem{
+				Put: &types.Put{
+					TableName: aws.String(participationTableName),
+					Item:      participationSerialized,
+				},
+			})
+		}
+	}
+
+	now := int(time.Now().Unix())
+	for _, mailJob := range mailJobs {
+		mailJob.Attempts = 0
+		mailJob.NextAttemptAt = now
+		mailJob.Status = "queued"
+		mailJob.Queue = "pending"
+		mailJobSerialized, err := attributevalue.MarshalMap(&mailJob)
+		if err != nil {
+			return fmt.Errorf("failed to serialize mail job")
+		}
+		transactItems = append(transactItems, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName: aws.String(outboxTableName),
+				Item:      mailJobSerialized,
+			},
+		})
+	}
+
+	// the mail of a removed participant would contain an invalidated confirmation link.
+	for key := range previousParticipants {
+		if _, ok := participants[key]; ok {
+			continue
+		}
+		transactItems = append(transactItems, types.TransactWriteItem{
+			Delete: &types.Delete{
+				TableName: aws.String(outboxTableName),
+				Key: map[string]types.AttributeValue{
+					"jobid": &types.AttributeValueMemberS{Value: fmt.Sprintf("%s#%s", gameid, key)},
+				},
+			},
+		})
+	}
+
+	_, err = dynamoClient.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
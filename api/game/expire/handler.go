@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/megakuul/leaderboard/api/game/expire/outbox"
+	"github.com/megakuul/leaderboard/api/game/expire/put"
+	"github.com/megakuul/leaderboard/api/game/expire/query"
+	"github.com/megakuul/leaderboard/api/game/expire/record"
+	"github.com/megakuul/leaderboard/api/game/expire/remove"
+)
+
+const (
+	// prefix of the ids that replace the subject of deleted users.
+	ANONYMOUS_ID_PREFIX = "deleted-"
+)
+
+func ExpireHandler(dynamoClient *dynamodb.Client) func(context.Context, events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+	return func(ctx context.Context, event events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+		response := events.DynamoDBEventResponse{
+			BatchItemFailures: []events.DynamoDBBatchItemFailure{},
+		}
+		for _, streamRecord := range event.Records {
+			if err := runExpireHandler(dynamoClient, &streamRecord, ctx); err != nil {
+				log.Printf("ERROR RECORD %s: %v\n", streamRecord.EventID, err)
+				// reported failures are retried by the event source mapping.
+				response.BatchItemFailures = append(response.BatchItemFailures, events.DynamoDBBatchItemFailure{
+					ItemIdentifier: streamRecord.Change.SequenceNumber,
+				})
+			}
+		}
+		return response, nil
+	}
+}
+
+func runExpireHandler(dynamoClient *dynamodb.Client, streamRecord *events.DynamoDBEventRecord, ctx context.Context) error {
+	// only deletions performed by the dynamodb ttl process are relevant.
+	if streamRecord.EventName != "REMOVE" || streamRecord.UserIdentity == nil ||
+		streamRecord.UserIdentity.Type != "Service" || streamRecord.UserIdentity.PrincipalID != "dynamodb.amazonaws.com" {
+		return nil
+	}
+
+	var game record.GameImage
+	if err := record.UnmarshalImage(streamRecord.Change.OldImage, &game); err != nil {
+		return fmt.Errorf("failed to deserialize game image: %v", err)
+	}
+	if game.Readonly {
+		return nil
+	}
+
+	unconfirmed := []string{}
+	auditParticipants := map[string]put.ParticipantInput{}
+	for key, part := range game.Participants {
+		if !part.Confirmed {
+			unconfirmed = append(unconfirmed, part.Username)
+		}
+		auditParticipants[key] = put.ParticipantInput{
+			Subject:   part.Subject,
+			Username:  part.Username,
+			Team:      part.Team,
+			Placement: part.Placement,
+			Points:    part.Points,
+			EloUpdate: part.EloUpdate,
+			Confirmed: part.Confirmed,
+		}
+	}
+
+	// games submitted before played_at was introduced are not indexed in the participations.
+	if playedAt, err := time.Parse(time.RFC3339, game.PlayedAt); err == nil {
+		subjects := []string{}
+		for _, part := range game.Participants {
+			subjects = append(subjects, part.Subject)
+		}
+		if err := remove.DeleteParticipations(dynamoClient, ctx, PARTICIPATIONTABLE, game.GameId, playedAt, subjects); err != nil {
+			return fmt.Errorf("failed to delete participations: %v", err)
+		}
+	}
+
+	// anonymized entries of deleted users are neither notified nor referenced.
+	recipientSubjects := map[string]bool{}
+	for _, part := range game.Participants {
+		if part.Deleted || part.Subject == "" {
+			continue
+		}
+		recipientSubjects[part.Subject] = part.Subject == game.Submitter
+	}
+	if game.Submitter != "" && !strings.HasPrefix(game.Submitter, ANONYMOUS_ID_PREFIX) {
+		recipientSubjects[game.Submitter] = true
+	}
+	auditSubjects := []string{}
+	for subject := range recipientSubjects {
+		auditSubjects = append(auditSubjects, subject)
+	}
+
+	emailExpireRequests := []outbox.EmailExpireRequest{}
+	for subject, isSubmitter := range recipientSubjects {
+		user, err := query.FetchBySubject(dynamoClient, ctx, USERTABLE, subject)
+		if err != nil {
+			log.Printf("WARNING: failed to lookup %s: %v\n", subject, err)
+			continue
+		}
+		// disabled users opted out of mails.
+		if user.Disabled {
+			continue
+		}
+		emailExpireRequests = append(emailExpireRequests, outbox.EmailExpireRequest{
+			Subject:   subject,
+			Username:  user.Username,
+			Email:     user.Email,
+			Submitter: isSubmitter,
+		})
+	}
+
+	mailJobs, err := outbox.BuildExpireJobs(MAILTEMPLATE, game.GameId, game.Date, unconfirmed, emailExpireRequests)
+	if err != nil {
+		return fmt.Errorf("failed to build expiration mails: %v", err)
+	}
+
+	// the audit record is written together with the mail jobs, it is used as marker to ensure
+	// that a retried record does not notify the participants twice.
+	err = put.InsertAudit(dynamoClient, ctx, AUDITTABLE, OUTBOXTABLE, &put.AuditInput{
+		GameId:       game.GameId,
+		Date:         game.Date,
+		Submitter:    game.Submitter,
+		Unconfirmed:  unconfirmed,
+		Participants: auditParticipants,
+	}, auditSubjects, AUDIT_RETENTION_DAYS, mailJobs)
+	if errors.Is(err, put.ErrAuditExists) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to insert audit record: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+var (
+	REGION               = os.Getenv("AWS_REGION")
+	USERTABLE            = os.Getenv("USERTABLE")
+	AUDITTABLE           = os.Getenv("AUDITTABLE")
+	PARTICIPATIONTABLE   = os.Getenv("PARTICIPATIONTABLE")
+	MAILTEMPLATE         = os.Getenv("MAILTEMPLATE")
+	OUTBOXTABLE          = os.Getenv("OUTBOXTABLE")
+	AUDIT_RETENTION_DAYS = 90 // default 90
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatalf("ERROR INITIALIZATION: %v\n", err)
+	}
+}
+
+func run() error {
+	awsConfig, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(REGION))
+	if err != nil {
+		return fmt.Errorf("failed to load aws config: %v", err)
+	}
+	dynamoClient := dynamodb.NewFromConfig(awsConfig)
+
+	if auditRetentionDays, err := strconv.Atoi(os.Getenv("AUDIT_RETENTION_DAYS")); err == nil {
+		AUDIT_RETENTION_DAYS = auditRetentionDays
+	}
+
+	lambda.Start(ExpireHandler(dynamoClient))
+	return nil
+}
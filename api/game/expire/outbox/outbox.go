@@ -0,0 +1,53 @@
+// contains helpers to build the expiration mail jobs of the outbox.
+// the jobs are written together with the audit record and delivered by the mailer.
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/megakuul/leaderboard/api/game/expire/put"
+)
+
+type EmailExpireRequest struct {
+	Subject   string
+	Username  string
+	Email     string
+	Submitter bool
+}
+
+type emailTemplateInput struct {
+	GameId      string   `json:"gameid"`
+	Date        string   `json:"date"`
+	Username    string   `json:"username"`
+	Submitter   bool     `json:"submitter"`
+	Unconfirmed []string `json:"unconfirmed"`
+}
+
+// BuildExpireJobs creates one expiration mail job per recipient.
+func BuildExpireJobs(mailTemplate, gameId, date string, unconfirmed []string, emailRequests []EmailExpireRequest) ([]put.MailJobInput, error) {
+	mailJobs := []put.MailJobInput{}
+	for _, request := range emailRequests {
+		templateInput := emailTemplateInput{
+			GameId:      gameId,
+			Date:        date,
+			Username:    request.Username,
+			Submitter:   request.Submitter,
+			Unconfirmed: unconfirmed,
+		}
+		templateInputSerialized, err := json.Marshal(&templateInput)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize mail input")
+		}
+		mailJobs = append(mailJobs, put.MailJobInput{
+			JobId:        fmt.Sprintf("%s#%s#%s", gameId, request.Subject, put.MAIL_KIND_EXPIRE),
+			Kind:         put.MAIL_KIND_EXPIRE,
+			GameId:       gameId,
+			Participant:  request.Subject,
+			Email:        request.Email,
+			Template:     mailTemplate,
+			TemplateData: string(templateInputSerialized),
+		})
+	}
+	return mailJobs, nil
+}
@@ -0,0 +1,142 @@
+// contains wrappers for database put functions.
+// main purpose is to abstract some boilerplate code
+// away from the handler.
+package put
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	AUDIT_EVENT_EXPIRED = "expired"
+	// expiration mails are not recorded on the participant, as the game no longer exists.
+	MAIL_KIND_EXPIRE = "expire"
+)
+
+// ErrAuditExists indicates that the audit record was already written (e.g. by a previous invocation).
+var ErrAuditExists = errors.New("audit record already exists")
+
+type ParticipantInput struct {
+	Subject   string `dynamodbav:"subject"`
+	Username  string `dynamodbav:"username"`
+	Team      int    `dynamodbav:"team"`
+	Placement int    `dynamodbav:"placement"`
+	Points    int    `dynamodbav:"points"`
+	EloUpdate int    `dynamodbav:"elo_update"`
+	Confirmed bool   `dynamodbav:"confirmed"`
+}
+
+type AuditInput struct {
+	GameId       string                      `dynamodbav:"gameid"`
+	Event        string                      `dynamodbav:"audit_event"`
+	Time         int                         `dynamodbav:"audit_time"`
+	ExpiresIn    int                         `dynamodbav:"expires_in"`
+	Date         string                      `dynamodbav:"game_date"`
+	Submitter    string                      `dynamodbav:"submitter"`
+	Unconfirmed  []string                    `dynamodbav:"unconfirmed"`
+	Participants map[string]ParticipantInput `dynamodbav:"participants"`
+}
+
+// AuditSubjectInput references the audit record from a subject of the game (participant or submitter).
+// the entries are indexed in the subject_gsi, so that the audit records of a user can be exported and anonymized.
+type AuditSubjectInput struct {
+	GameId    string `dynamodbav:"gameid"`
+	Event     string `dynamodbav:"audit_event"`
+	Ref       string `dynamodbav:"audit_ref"`
+	Subject   string `dynamodbav:"subject"`
+	ExpiresIn int    `dynamodbav:"expires_in"`
+}
+
+// MailJobInput is a mail job of the outbox, it is delivered by the mailer.
+type MailJobInput struct {
+	JobId         string `dynamodbav:"jobid"`
+	Kind          string `dynamodbav:"kind"`
+	GameId        string `dynamodbav:"gameid"`
+	Participant   string `dynamodbav:"participant"`
+	Email         string `dynamodbav:"email"`
+	Template      string `dynamodbav:"template"`
+	TemplateData  string `dynamodbav:"template_data"`
+	Attempts      int    `dynamodbav:"attempts"`
+	NextAttemptAt int    `dynamodbav:"next_attempt_at"`
+	Status        string `dynamodbav:"status"`
+	Queue         string `dynamodbav:"queue"`
+}
+
+// InsertAudit writes the audit record of an expired game together with the subject references and the expiration mail jobs in one transaction.
+// the audit record is used as marker, if it was already written, ErrAuditExists is returned and no job is enqueued.
+func InsertAudit(dynamoClient *dynamodb.Client, ctx context.Context, tableName, outboxTableName string, auditInput *AuditInput, subjects []string, retentionDays int, mailJobs []MailJobInput) error {
+	auditInput.Event = AUDIT_EVENT_EXPIRED
+	auditInput.Time = int(time.Now().Unix())
+	auditInput.ExpiresIn = int(time.Now().Add(time.Duration(retentionDays) * 24 * time.Hour).Unix())
+
+	auditInputSerialized, err := attributevalue.MarshalMap(auditInput)
+	if err != nil {
+		return fmt.Errorf("failed to serialize put input")
+	}
+
+	transactItems := []types.TransactWriteItem{{
+		Put: &types.Put{
+			TableName:           aws.String(tableName),
+			Item:                auditInputSerialized,
+			ConditionExpression: aws.String("attribute_not_exists(gameid)"),
+		},
+	}}
+
+	for _, subject := range subjects {
+		auditSubjectSerialized, err := attributevalue.MarshalMap(&AuditSubjectInput{
+			GameId:    auditInput.GameId,
+			Event:     fmt.Sprintf("%s#%s", auditInput.Event, subject),
+			Ref:       auditInput.Event,
+			Subject:   subject,
+			ExpiresIn: auditInput.ExpiresIn,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to serialize audit subject")
+		}
+		transactItems = append(transactItems, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName: aws.String(tableName),
+				Item:      auditSubjectSerialized,
+			},
+		})
+	}
+
+	now := int(time.Now().Unix())
+	for _, mailJob := range mailJobs {
+		mailJob.Attempts = 0
+		mailJob.NextAttemptAt = now
+		mailJob.Status = "queued"
+		mailJob.Queue = "pending"
+		mailJobSerialized, err := attributevalue.MarshalMap(&mailJob)
+		if err != nil {
+			return fmt.Errorf("failed to serialize mail job")
+		}
+		transactItems = append(transactItems, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName: aws.String(outboxTableName),
+				Item:      mailJobSerialized,
+			},
+		})
+	}
+
+	_, err = dynamoClient.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		var cancelErr *types.TransactionCanceledException
+		if errors.As(err, &cancelErr) && len(cancelErr.CancellationReasons) > 0 &&
+			aws.ToString(cancelErr.CancellationReasons[0].Code) == "ConditionalCheckFailed" {
+			return ErrAuditExists
+		}
+		return err
+	}
+	return nil
+}
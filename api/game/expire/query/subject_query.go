@@ -0,0 +1,32 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func FetchBySubject(dynamoClient *dynamodb.Client, ctx context.Context, tableName string, subject string) (*UserOutput, error) {
+	output, err := dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"subject": &types.AttributeValueMemberS{Value: subject},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if output.Item == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	var user UserOutput
+	err = attributevalue.UnmarshalMap(output.Item, &user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,21 @@
+package record
+
+type ParticipantImage struct {
+	Subject   string `dynamodbav:"subject"`
+	Username  string `dynamodbav:"username"`
+	Team      int    `dynamodbav:"team"`
+	Placement int    `dynamodbav:"placement"`
+	Points    int    `dynamodbav:"points"`
+	EloUpdate int    `dynamodbav:"elo_update"`
+	Confirmed bool   `dynamodbav:"confirmed"`
+	Deleted   bool   `dynamodbav:"deleted"`
+}
+
+type GameImage struct {
+	GameId       string                      `dynamodbav:"gameid"`
+	Date         string                      `dynamodbav:"game_date"`
+	PlayedAt     string                      `dynamodbav:"played_at"`
+	Readonly     bool                        `dynamodbav:"readonly"`
+	Submitter    string                      `dynamodbav:"submitter"`
+	Participants map[string]ParticipantImage `dynamodbav:"participants"`
+}
@@ -0,0 +1,72 @@
+// contains helpers to parse dynamodb stream records.
+// stream images use the lambda event format, which is not compatible
+// with the attributevalue package of the aws sdk.
+package record
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// UnmarshalImage deserializes a stream image into the provided output struct.
+func UnmarshalImage(image map[string]events.DynamoDBAttributeValue, out interface{}) error {
+	convertedImage, err := convertMap(image)
+	if err != nil {
+		return err
+	}
+	return attributevalue.UnmarshalMap(convertedImage, out)
+}
+
+func convertMap(image map[string]events.DynamoDBAttributeValue) (map[string]types.AttributeValue, error) {
+	convertedMap := map[string]types.AttributeValue{}
+	for key, value := range image {
+		convertedValue, err := convertValue(value)
+		if err != nil {
+			return nil, err
+		}
+		convertedMap[key] = convertedValue
+	}
+	return convertedMap, nil
+}
+
+func convertValue(value events.DynamoDBAttributeValue) (types.AttributeValue, error) {
+	switch value.DataType() {
+	case events.DataTypeString:
+		return &types.AttributeValueMemberS{Value: value.String()}, nil
+	case events.DataTypeNumber:
+		return &types.AttributeValueMemberN{Value: value.Number()}, nil
+	case events.DataTypeBoolean:
+		return &types.AttributeValueMemberBOOL{Value: value.Boolean()}, nil
+	case events.DataTypeBinary:
+		return &types.AttributeValueMemberB{Value: value.Binary()}, nil
+	case events.DataTypeNull:
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+	case events.DataTypeStringSet:
+		return &types.AttributeValueMemberSS{Value: value.StringSet()}, nil
+	case events.DataTypeNumberSet:
+		return &types.AttributeValueMemberNS{Value: value.NumberSet()}, nil
+	case events.DataTypeBinarySet:
+		return &types.AttributeValueMemberBS{Value: value.BinarySet()}, nil
+	case events.DataTypeList:
+		convertedList := []types.AttributeValue{}
+		for _, item := range value.List() {
+			convertedItem, err := convertValue(item)
+			if err != nil {
+				return nil, err
+			}
+			convertedList = append(convertedList, convertedItem)
+		}
+		return &types.AttributeValueMemberL{Value: convertedList}, nil
+	case events.DataTypeMap:
+		convertedMap, err := convertMap(value.Map())
+		if err != nil {
+			return nil, err
+		}
+		return &types.AttributeValueMemberM{Value: convertedMap}, nil
+	default:
+		return nil, fmt.Errorf("unsupported attribute type %d", value.DataType())
+	}
+}
@@ -0,0 +1,83 @@
+// contains wrappers for database delete functions.
+// main purpose is to abstract some boilerplate code
+// away from the handler.
+package remove
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// maximum number of requests dynamodb accepts in one batch write.
+	MAX_BATCH_WRITE = 25
+	// maximum number of retries of unprocessed (throttled) requests, afterwards the stream retries the record.
+	MAX_BATCH_RETRIES = 5
+	// delay before the first retry of unprocessed requests, it is doubled on every retry.
+	BATCH_RETRY_BASE_DELAY = 50 * time.Millisecond
+)
+
+// DeleteParticipations removes the participations of an expired game from the participant index.
+// deletions are idempotent, therefore retried records can safely delete them again.
+func DeleteParticipations(dynamoClient *dynamodb.Client, ctx context.Context, tableName, gameid string, playedAt time.Time, subjects []string) error {
+	sortKey := fmt.Sprintf("%s#%s", playedAt.UTC().Format(time.RFC3339), gameid)
+	writeRequests := []types.WriteRequest{}
+	for _, subject := range subjects {
+		writeRequests = append(writeRequests, types.WriteRequest{
+			DeleteRequest: &types.DeleteRequest{
+				Key: map[string]types.AttributeValue{
+					"subject":          &types.AttributeValueMemberS{Value: subject},
+					"played_at_gameid": &types.AttributeValueMemberS{Value: sortKey},
+				},
+			},
+		})
+	}
+
+	for start := 0; start < len(writeRequests); start += MAX_BATCH_WRITE {
+		end := min(start+MAX_BATCH_WRITE, len(writeRequests))
+		requestItems := map[string][]types.WriteRequest{
+			tableName: writeRequests[start:end],
+		}
+		for retries := 0; len(requestItems) > 0; retries++ {
+			if retries > MAX_BATCH_RETRIES {
+				return fmt.Errorf("participations remain unprocessed after %d retries", MAX_BATCH_RETRIES)
+			}
+			if retries > 0 {
+				if err := sleep(ctx, backoff(retries)); err != nil {
+					return err
+				}
+			}
+			output, err := dynamoClient.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+				RequestItems: requestItems,
+			})
+			if err != nil {
+				return err
+			}
+			requestItems = output.UnprocessedItems
+		}
+	}
+	return nil
+}
+
+// backoff calculates the exponential delay before the retry (with up to 20% jitter).
+func backoff(retries int) time.Duration {
+	delay := BATCH_RETRY_BASE_DELAY << (retries - 1)
+	return delay + time.Duration(rand.Int64N(int64(delay)/5+1))
+}
+
+// sleep waits for the delay, it returns early if the context is cancelled (e.g. the function times out).
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -3,8 +3,11 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -12,13 +15,14 @@ import (
 )
 
 type FetchResponse struct {
-	Message string             `json:"message"`
-	Games   []query.GameOutput `json:"games"`
+	Message    string             `json:"message"`
+	NewPageKey string             `json:"newpagekey"`
+	Games      []query.GameOutput `json:"games"`
 }
 
-func FetchHandler(dynamoClient *dynamodb.Client) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+func FetchHandler(dynamoClient *dynamodb.Client, pageKeySigner *query.PageKeySigner) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
 	return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
-		response, code, err := runFetchHandler(dynamoClient, &request, ctx)
+		response, code, err := runFetchHandler(dynamoClient, pageKeySigner, &request, ctx)
 		if err != nil {
 			return events.APIGatewayV2HTTPResponse{
 				StatusCode: code,
@@ -42,28 +46,109 @@ func FetchHandler(dynamoClient *dynamodb.Client) func(context.Context, events.AP
 	}
 }
 
-func runFetchHandler(dynamoClient *dynamodb.Client, request *events.APIGatewayV2HTTPRequest, ctx context.Context) (*FetchResponse, int, error) {
+func runFetchHandler(dynamoClient *dynamodb.Client, pageKeySigner *query.PageKeySigner, request *events.APIGatewayV2HTTPRequest, ctx context.Context) (*FetchResponse, int, error) {
 	gameid, ok := request.QueryStringParameters["gameid"]
 	if ok && gameid != "" {
 		games, err := query.FetchByGameId(dynamoClient, ctx, GAMETABLE, gameid)
 		if err != nil {
 			return nil, http.StatusNotFound, fmt.Errorf("failed to fetch data by gameid: %v", err)
 		}
+		if err := query.ResolveCurrentUsernames(dynamoClient, ctx, USERTABLE, games); err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to resolve current usernames: %v", err)
+		}
 		return &FetchResponse{
 			Message: "successfully fetched data by gameid",
 			Games:   games,
 		}, http.StatusOK, nil
 	}
-	date, ok := request.QueryStringParameters["date"]
-	if ok && date != "" {
-		games, err := query.FetchByDate(dynamoClient, ctx, GAMETABLE, date)
+	pageSize, err := strconv.Atoi(request.QueryStringParameters["pagesize"])
+	if err != nil {
+		pageSize = query.MAX_PAGESIZE
+	}
+	lastPageKey := request.QueryStringParameters["lastpagekey"]
+
+	// a single date is handled as range with one day.
+	from := request.QueryStringParameters["from"]
+	to := request.QueryStringParameters["to"]
+	if date := request.QueryStringParameters["date"]; date != "" {
+		from, to = date, date
+	}
+	if from != "" {
+		if to == "" {
+			to = from
+		}
+		fromDate, err := time.Parse(query.DATE_FORMAT, from)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid from date: expected format %s", query.DATE_FORMAT)
+		}
+		toDate, err := time.Parse(query.DATE_FORMAT, to)
 		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid to date: expected format %s", query.DATE_FORMAT)
+		}
+		if toDate.Before(fromDate) {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid date range: to must not be before from")
+		}
+		if toDate.Sub(fromDate) >= time.Duration(MAX_DATE_RANGE_DAYS)*24*time.Hour {
+			return nil, http.StatusBadRequest, fmt.Errorf("maximum date range is %d days", MAX_DATE_RANGE_DAYS)
+		}
+
+		filter := query.DateFilter{}
+		if filter.Readonly, err = parseBoolParam(request.QueryStringParameters["readonly"]); err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid readonly filter: %v", err)
+		}
+		if filter.Pending, err = parseBoolParam(request.QueryStringParameters["pending"]); err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid pending filter: %v", err)
+		}
+
+		games, newPageKey, err := query.FetchByDateRange(dynamoClient, ctx, pageKeySigner, GAMETABLE, fromDate, toDate, &filter, int32(pageSize), lastPageKey)
+		if errors.Is(err, query.ErrInvalidPageKey) {
+			return nil, http.StatusBadRequest, fmt.Errorf("failed to fetch data by date: %v", err)
+		} else if err != nil {
 			return nil, http.StatusNotFound, fmt.Errorf("failed to fetch data by date: %v", err)
 		}
+		if err := query.ResolveCurrentUsernames(dynamoClient, ctx, USERTABLE, games); err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to resolve current usernames: %v", err)
+		}
 		return &FetchResponse{
-			Message: "successfully fetched data by date",
-			Games:   games,
+			Message:    "successfully fetched data by date",
+			NewPageKey: newPageKey,
+			Games:      games,
+		}, http.StatusOK, nil
+	}
+
+	username, ok := request.QueryStringParameters["username"]
+	if ok && username != "" {
+		subject, err := query.FetchSubjectByUsername(dynamoClient, ctx, USERTABLE, USERNAMEHISTORYTABLE, username)
+		if err != nil {
+			return nil, http.StatusNotFound, fmt.Errorf("failed to fetch data by username: %v", err)
+		}
+		games, newPageKey, err := query.FetchByParticipant(dynamoClient, ctx, pageKeySigner, PARTICIPATIONTABLE, GAMETABLE, subject,
+			int32(pageSize), lastPageKey)
+		if errors.Is(err, query.ErrInvalidPageKey) {
+			return nil, http.StatusBadRequest, fmt.Errorf("failed to fetch data by username: %v", err)
+		} else if err != nil {
+			return nil, http.StatusNotFound, fmt.Errorf("failed to fetch data by username: %v", err)
+		}
+		if err := query.ResolveCurrentUsernames(dynamoClient, ctx, USERTABLE, games); err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to resolve current usernames: %v", err)
+		}
+		return &FetchResponse{
+			Message:    "successfully fetched data by username",
+			NewPageKey: newPageKey,
+			Games:      games,
 		}, http.StatusOK, nil
 	}
 	return nil, http.StatusBadRequest, fmt.Errorf("failed to fetch data: no search param provided")
 }
+
+// parseBoolParam parses an optional boolean query parameter, nil is returned if the parameter is unset.
+func parseBoolParam(param string) (*bool, error) {
+	if param == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseBool(param)
+	if err != nil {
+		return nil, fmt.Errorf("expected true or false")
+	}
+	return &value, nil
+}
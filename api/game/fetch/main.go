@@ -5,15 +5,24 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/megakuul/leaderboard/api/game/fetch/query"
 )
 
 var (
-	REGION    = os.Getenv("AWS_REGION")
-	GAMETABLE = os.Getenv("GAMETABLE")
+	REGION               = os.Getenv("AWS_REGION")
+	USERTABLE            = os.Getenv("USERTABLE")
+	USERNAMEHISTORYTABLE = os.Getenv("USERNAMEHISTORYTABLE")
+	GAMETABLE            = os.Getenv("GAMETABLE")
+	PARTICIPATIONTABLE   = os.Getenv("PARTICIPATIONTABLE")
+	MAX_DATE_RANGE_DAYS  = 31 // default 31
+	CURSOR_SECRET        = os.Getenv("CURSOR_SECRET")
+	CURSOR_TTL_MINUTES   = 60 // default 60
 )
 
 func main() {
@@ -29,6 +38,21 @@ func run() error {
 	}
 	dynamoClient := dynamodb.NewFromConfig(awsConfig)
 
-	lambda.Start(FetchHandler(dynamoClient))
+	if maxDateRangeDays, err := strconv.Atoi(os.Getenv("MAX_DATE_RANGE_DAYS")); err == nil {
+		MAX_DATE_RANGE_DAYS = maxDateRangeDays
+	}
+
+	if cursorTtlMinutes, err := strconv.Atoi(os.Getenv("CURSOR_TTL_MINUTES")); err == nil {
+		CURSOR_TTL_MINUTES = cursorTtlMinutes
+	}
+	if CURSOR_SECRET == "" {
+		return fmt.Errorf("no CURSOR_SECRET provided")
+	}
+	pageKeySigner := &query.PageKeySigner{
+		Secret: []byte(CURSOR_SECRET),
+		TTL:    time.Duration(CURSOR_TTL_MINUTES) * time.Minute,
+	}
+
+	lambda.Start(FetchHandler(dynamoClient, pageKeySigner))
 	return nil
 }
@@ -0,0 +1,68 @@
+package query
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ResolveCurrentUsernames sets the current username of every participant of the games.
+// participants store the username they had when the game was submitted, users that no longer exist are skipped.
+func ResolveCurrentUsernames(dynamoClient *dynamodb.Client, ctx context.Context, tableName string, games []GameOutput) error {
+	subjects := []string{}
+	seen := map[string]bool{}
+	for _, game := range games {
+		for _, part := range game.Participants {
+			if part.Subject == "" || seen[part.Subject] {
+				continue
+			}
+			seen[part.Subject] = true
+			subjects = append(subjects, part.Subject)
+		}
+	}
+
+	usernames := map[string]string{}
+	for start := 0; start < len(subjects); start += MAX_BATCH_GET {
+		end := min(start+MAX_BATCH_GET, len(subjects))
+		keys := []map[string]types.AttributeValue{}
+		for _, subject := range subjects[start:end] {
+			keys = append(keys, map[string]types.AttributeValue{
+				"subject": &types.AttributeValueMemberS{Value: subject},
+			})
+		}
+		requestItems := map[string]types.KeysAndAttributes{
+			tableName: {
+				Keys:                     keys,
+				ProjectionExpression:     aws.String("#subject, #username"),
+				ExpressionAttributeNames: map[string]string{"#subject": "subject", "#username": "username"},
+			},
+		}
+		for len(requestItems) > 0 {
+			output, err := dynamoClient.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+				RequestItems: requestItems,
+			})
+			if err != nil {
+				return err
+			}
+			var users []UserOutput
+			if err := attributevalue.UnmarshalListOfMaps(output.Responses[tableName], &users); err != nil {
+				return err
+			}
+			for _, user := range users {
+				usernames[user.Subject] = user.Username
+			}
+			requestItems = output.UnprocessedKeys
+		}
+	}
+
+	for _, game := range games {
+		for key, part := range game.Participants {
+			part.CurrentUsername = usernames[part.Subject]
+			game.Participants[key] = part
+		}
+	}
+	return nil
+}
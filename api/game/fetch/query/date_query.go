@@ -2,6 +2,10 @@ package query
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -9,22 +13,143 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
-func FetchByDate(dynamoClient *dynamodb.Client, ctx context.Context, tableName string, date string) ([]GameOutput, error) {
-	output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
-		TableName: aws.String(tableName),
-		IndexName: aws.String("date_gsi"),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":game_date": &types.AttributeValueMemberS{Value: date},
-		},
-		KeyConditionExpression: aws.String("game_date = :game_date"),
-	})
+const (
+	DATE_FORMAT = "2006-01-02"
+)
+
+// DateFilter restricts the games returned by FetchByDateRange. nil fields are not filtered.
+type DateFilter struct {
+	Readonly *bool
+	Pending  *bool
+}
+
+// datePageKey is the position in the date range, it contains the date of the current partition
+// and the last evaluated key inside of this partition (empty if the partition starts from the beginning).
+type datePageKey struct {
+	Date string                 `json:"date"`
+	Key  map[string]interface{} `json:"key,omitempty"`
+}
+
+// FetchByDateRange fetches the games played between from and to (inclusive, ascending by date).
+// the date_gsi is partitioned by date, therefore every date of the range is queried one after another
+// until the page is full.
+func FetchByDateRange(dynamoClient *dynamodb.Client, ctx context.Context, signer *PageKeySigner, tableName string, from, to time.Time, filter *DateFilter, pageSize int32, lastPageKey string) ([]GameOutput, string, error) {
+	if pageSize > MAX_PAGESIZE || pageSize < 1 {
+		pageSize = MAX_PAGESIZE
+	}
+	shape := fmt.Sprintf("date:%s:%s:%s:%s", from.Format(DATE_FORMAT), to.Format(DATE_FORMAT),
+		filterShape(filter.Readonly), filterShape(filter.Pending))
+
+	date := from
+	var pageKey map[string]types.AttributeValue = nil
+	if lastPageKey != "" {
+		var err error
+		date, pageKey, err = deserializeDatePageKey(signer, lastPageKey, shape)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to deserialize lastPageKey: %w", err)
+		}
+		if date.Before(from) || date.After(to) {
+			return nil, "", fmt.Errorf("failed to deserialize lastPageKey: %w: page key is outside of the date range", ErrInvalidPageKey)
+		}
+	}
+
+	expressionAttributeNames := map[string]string{}
+	expressionAttributeValues := map[string]types.AttributeValue{}
+	filterExpressions := []string{}
+	if filter.Readonly != nil {
+		expressionAttributeNames["#readonly"] = "readonly"
+		expressionAttributeValues[":readonly"] = &types.AttributeValueMemberBOOL{Value: *filter.Readonly}
+		filterExpressions = append(filterExpressions, "#readonly = :readonly")
+	}
+	if filter.Pending != nil {
+		// pending is only set on pending games (see pending_gsi).
+		expressionAttributeNames["#pending"] = "pending"
+		if *filter.Pending {
+			filterExpressions = append(filterExpressions, "attribute_exists(#pending)")
+		} else {
+			filterExpressions = append(filterExpressions, "attribute_not_exists(#pending)")
+		}
+	}
+	var filterExpression *string = nil
+	if len(filterExpressions) > 0 {
+		filterExpression = aws.String(strings.Join(filterExpressions, " AND "))
+	}
+	expressionAttributeNames["#game_date"] = "game_date"
+
+	games := []GameOutput{}
+	for !date.After(to) && int32(len(games)) < pageSize {
+		expressionAttributeValues[":game_date"] = &types.AttributeValueMemberS{Value: date.Format(DATE_FORMAT)}
+		output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
+			TableName:                 aws.String(tableName),
+			IndexName:                 aws.String("date_gsi"),
+			ExpressionAttributeNames:  expressionAttributeNames,
+			ExpressionAttributeValues: expressionAttributeValues,
+			KeyConditionExpression:    aws.String("#game_date = :game_date"),
+			FilterExpression:          filterExpression,
+			Limit:                     aws.Int32(pageSize - int32(len(games))),
+			ExclusiveStartKey:         pageKey,
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		var page []GameOutput
+		err = attributevalue.UnmarshalListOfMaps(output.Items, &page)
+		if err != nil {
+			return nil, "", err
+		}
+		games = append(games, page...)
+
+		if len(output.LastEvaluatedKey) > 0 {
+			pageKey = output.LastEvaluatedKey
+		} else {
+			date = date.AddDate(0, 0, 1)
+			pageKey = nil
+		}
+	}
+
+	if date.After(to) {
+		return games, "", nil
+	}
+	newPageKey, err := serializeDatePageKey(signer, shape, date, pageKey)
 	if err != nil {
-		return nil, err
+		return nil, "", fmt.Errorf("failed to serialize new pagekey: %v", err)
+	}
+	return games, newPageKey, nil
+}
+
+// filterShape serializes an optional filter for the query shape of the page key.
+func filterShape(filter *bool) string {
+	if filter == nil {
+		return "any"
+	}
+	return strconv.FormatBool(*filter)
+}
+
+func serializeDatePageKey(signer *PageKeySigner, shape string, date time.Time, pageKey map[string]types.AttributeValue) (string, error) {
+	key := datePageKey{Date: date.Format(DATE_FORMAT)}
+	if len(pageKey) > 0 {
+		if err := attributevalue.UnmarshalMap(pageKey, &key.Key); err != nil {
+			return "", err
+		}
+	}
+	return signer.sign(shape, &key)
+}
+
+func deserializeDatePageKey(signer *PageKeySigner, pageKey, shape string) (time.Time, map[string]types.AttributeValue, error) {
+	var key datePageKey
+	if err := signer.verify(pageKey, shape, &key); err != nil {
+		return time.Time{}, nil, err
+	}
+	date, err := time.Parse(DATE_FORMAT, key.Date)
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("%w: malformed date", ErrInvalidPageKey)
+	}
+	if len(key.Key) < 1 {
+		return date, nil, nil
 	}
-	var games []GameOutput
-	err = attributevalue.UnmarshalListOfMaps(output.Items, &games)
+	partitionKey, err := attributevalue.MarshalMap(key.Key)
 	if err != nil {
-		return nil, err
+		return time.Time{}, nil, err
 	}
-	return games, nil
+	return date, partitionKey, nil
 }
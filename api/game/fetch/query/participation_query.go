@@ -0,0 +1,109 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type participationOutput struct {
+	GameId string `dynamodbav:"gameid"`
+}
+
+// FetchByParticipant fetches the games of a participant (latest games first) via the participant index.
+// games that no longer exist (e.g. expired games whose participations are not yet removed) are skipped.
+func FetchByParticipant(dynamoClient *dynamodb.Client, ctx context.Context, signer *PageKeySigner, participationTableName, gameTableName, subject string, pageSize int32, lastPageKey string) ([]GameOutput, string, error) {
+	if pageSize > MAX_PAGESIZE || pageSize < 1 {
+		pageSize = MAX_PAGESIZE
+	}
+	shape := fmt.Sprintf("participant:%s", subject)
+
+	var pageKey map[string]types.AttributeValue = nil
+	if lastPageKey != "" {
+		var err error
+		pageKey, err = signer.deserializePageKey(lastPageKey, shape)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to deserialize lastPageKey: %w", err)
+		}
+	}
+
+	output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
+		TableName: aws.String(participationTableName),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":subject": &types.AttributeValueMemberS{Value: subject},
+		},
+		KeyConditionExpression: aws.String("subject = :subject"),
+		Limit:                  aws.Int32(pageSize),
+		ScanIndexForward:       aws.Bool(false),
+		ExclusiveStartKey:      pageKey,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	var participations []participationOutput
+	err = attributevalue.UnmarshalListOfMaps(output.Items, &participations)
+	if err != nil {
+		return nil, "", err
+	}
+
+	games, err := fetchGamesByIds(dynamoClient, ctx, gameTableName, participations)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(output.LastEvaluatedKey) < 1 {
+		return games, "", nil
+	}
+	newPageKey, err := signer.serializePageKey(shape, output.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to serialize new pagekey: %v", err)
+	}
+	return games, newPageKey, nil
+}
+
+// fetchGamesByIds loads the games of the participations and keeps the order of the participations.
+func fetchGamesByIds(dynamoClient *dynamodb.Client, ctx context.Context, tableName string, participations []participationOutput) ([]GameOutput, error) {
+	games := []GameOutput{}
+	if len(participations) < 1 {
+		return games, nil
+	}
+
+	keys := []map[string]types.AttributeValue{}
+	for _, participation := range participations {
+		keys = append(keys, map[string]types.AttributeValue{
+			"gameid": &types.AttributeValueMemberS{Value: participation.GameId},
+		})
+	}
+
+	gamesById := map[string]GameOutput{}
+	requestItems := map[string]types.KeysAndAttributes{
+		tableName: {Keys: keys},
+	}
+	for len(requestItems) > 0 {
+		output, err := dynamoClient.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+			RequestItems: requestItems,
+		})
+		if err != nil {
+			return nil, err
+		}
+		var page []GameOutput
+		if err := attributevalue.UnmarshalListOfMaps(output.Responses[tableName], &page); err != nil {
+			return nil, err
+		}
+		for _, game := range page {
+			gamesById[game.GameId] = game
+		}
+		requestItems = output.UnprocessedKeys
+	}
+
+	for _, participation := range participations {
+		if game, ok := gamesById[participation.GameId]; ok {
+			games = append(games, game)
+		}
+	}
+	return games, nil
+}
@@ -3,21 +3,76 @@
 // dynamodb tools (indexes, etc.)
 package query
 
+import (
+	"encoding/json"
+	"sort"
+)
+
+const (
+	MAX_PAGESIZE = 100
+	// maximum number of keys dynamodb accepts in one batch get.
+	MAX_BATCH_GET = 100
+)
+
+type UserOutput struct {
+	Subject  string `dynamodbav:"subject"`
+	Username string `dynamodbav:"username"`
+}
+
 type ParticipantOutput struct {
-	Username  string `dynamodbav:"username" json:"username"`
-	Underdog  bool   `dynamodbav:"underdog" json:"underdog"`
-	Team      int    `dynamodbav:"team" json:"team"`
-	Placement int    `dynamodbav:"placement" json:"placement"`
-	Points    int    `dynamodbav:"points" json:"points"`
-	Elo       int    `dynamodbav:"elo" json:"elo"`
-	EloUpdate int    `dynamodbav:"elo_update" json:"elo_update"`
-	Confirmed bool   `dynamodbav:"confirmed" json:"confirmed"`
+	Subject          string `dynamodbav:"subject" json:"-"`
+	Username         string `dynamodbav:"username" json:"username"`
+	CurrentUsername  string `dynamodbav:"-" json:"current_username,omitempty"`
+	Underdog         bool   `dynamodbav:"underdog" json:"underdog"`
+	Team             int    `dynamodbav:"team" json:"team"`
+	Placement        int    `dynamodbav:"placement" json:"placement"`
+	Points           int    `dynamodbav:"points" json:"points"`
+	Elo              int    `dynamodbav:"elo" json:"elo"`
+	EloUpdate        int    `dynamodbav:"elo_update" json:"elo_update"`
+	Confirmed        bool   `dynamodbav:"confirmed" json:"confirmed"`
+	AcceptedByQuorum bool   `dynamodbav:"accepted_by_quorum" json:"accepted_by_quorum"`
+	MailStatus       string `dynamodbav:"mail_status" json:"mail_status,omitempty"`
+	Deleted          bool   `dynamodbav:"deleted" json:"deleted,omitempty"`
+}
+
+// ParticipantsOutput contains the participants of a game keyed by subject.
+// it is serialized as list (sorted by placement), so that the subjects are not exposed as json keys.
+type ParticipantsOutput map[string]ParticipantOutput
+
+func (p ParticipantsOutput) MarshalJSON() ([]byte, error) {
+	participants := make([]ParticipantOutput, 0, len(p))
+	for _, part := range p {
+		participants = append(participants, part)
+	}
+	sort.Slice(participants, func(i, j int) bool {
+		if participants[i].Placement != participants[j].Placement {
+			return participants[i].Placement < participants[j].Placement
+		}
+		return participants[i].Username < participants[j].Username
+	})
+	return json.Marshal(participants)
+}
+
+type HistoryOutput struct {
+	EditedAt     int                `dynamodbav:"edited_at" json:"edited_at"`
+	Participants ParticipantsOutput `dynamodbav:"participants" json:"participants"`
 }
 
 type GameOutput struct {
-	GameId       string                       `dynamodbav:"gameid" json:"gameid"`
-	Date         string                       `dynamodbav:"game_date" json:"date"`
-	Readonly     bool                         `dynamodbav:"readonly" json:"readonly"`
-	ExpiresIn    int                          `dynamodbav:"expires_in" json:"expires_in"`
-	Participants map[string]ParticipantOutput `dynamodbav:"participants" json:"participants"`
+	GameId       string             `dynamodbav:"gameid" json:"gameid"`
+	Date         string             `dynamodbav:"game_date" json:"date"`
+	PlayedAt     string             `dynamodbav:"played_at" json:"played_at"`
+	Name         string             `dynamodbav:"name" json:"name"`
+	Location     string             `dynamodbav:"location" json:"location"`
+	Notes        string             `dynamodbav:"notes" json:"notes"`
+	Readonly     bool               `dynamodbav:"readonly" json:"readonly"`
+	ExpiresIn    int                `dynamodbav:"expires_in" json:"expires_in"`
+	QuorumPolicy string             `dynamodbav:"quorum_policy" json:"quorum_policy"`
+	Reverted     bool               `dynamodbav:"reverted" json:"reverted"`
+	RevertReason string             `dynamodbav:"revert_reason" json:"revert_reason,omitempty"`
+	RevertedBy   string             `dynamodbav:"reverted_by" json:"reverted_by,omitempty"`
+	RevertedAt   int                `dynamodbav:"reverted_at" json:"reverted_at,omitempty"`
+	EditedAt     int                `dynamodbav:"edited_at" json:"edited_at,omitempty"`
+	History      []HistoryOutput    `dynamodbav:"history" json:"history,omitempty"`
+	Participants ParticipantsOutput `dynamodbav:"participants" json:"participants"`
 }
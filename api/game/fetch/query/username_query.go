@@ -0,0 +1,54 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// FetchSubjectByUsername resolves the subject of the user with the specified username.
+// previous usernames of renamed users are resolved via the username history.
+func FetchSubjectByUsername(dynamoClient *dynamodb.Client, ctx context.Context, tableName, historyTableName, username string) (string, error) {
+	output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
+		TableName: aws.String(tableName),
+		IndexName: aws.String("username_gsi"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":username": &types.AttributeValueMemberS{Value: username},
+		},
+		KeyConditionExpression: aws.String("username = :username"),
+		Limit:                  aws.Int32(1),
+	})
+	if err != nil {
+		return "", err
+	}
+	var users []UserOutput
+	err = attributevalue.UnmarshalListOfMaps(output.Items, &users)
+	if err != nil {
+		return "", err
+	}
+	if len(users) > 0 {
+		return users[0].Subject, nil
+	}
+
+	historyOutput, err := dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(historyTableName),
+		Key: map[string]types.AttributeValue{
+			"username": &types.AttributeValueMemberS{Value: username},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	if historyOutput.Item == nil {
+		return "", fmt.Errorf("user not found")
+	}
+	var user UserOutput
+	if err := attributevalue.UnmarshalMap(historyOutput.Item, &user); err != nil {
+		return "", err
+	}
+	return user.Subject, nil
+}
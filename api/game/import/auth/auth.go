@@ -0,0 +1,24 @@
+// contains the authorization checks on the cognito claims of the id token.
+// the same checks are used by the add, edit, import and revert functions (api/game/<function>/auth),
+// changes must be applied to all of them.
+package auth
+
+import "strings"
+
+// IsGroupMember checks if the cognito groups claim contains the specified group.
+// the api gateway serializes array claims as space separated list in brackets ("[group1 group2]"),
+// commas are accepted as separator as well. an empty group never matches (e.g. if the group is not configured).
+func IsGroupMember(claims map[string]string, group string) bool {
+	if group == "" {
+		return false
+	}
+	groups := strings.FieldsFunc(strings.Trim(claims["cognito:groups"], "[]"), func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+	for _, claimGroup := range groups {
+		if claimGroup == group {
+			return true
+		}
+	}
+	return false
+}
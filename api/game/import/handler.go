@@ -0,0 +1,369 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/megakuul/leaderboard/api/game/import/auth"
+	"github.com/megakuul/leaderboard/api/game/import/outbox"
+	"github.com/megakuul/leaderboard/api/game/import/parse"
+	"github.com/megakuul/leaderboard/api/game/import/put"
+	"github.com/megakuul/leaderboard/api/game/import/query"
+	"github.com/megakuul/leaderboard/api/game/import/quorum"
+	"github.com/megakuul/leaderboard/api/game/import/rating"
+)
+
+const (
+	MAX_NAME_LENGTH     = 50
+	MAX_LOCATION_LENGTH = 50
+	MAX_NOTES_LENGTH    = 500
+	// played_at timestamps are accepted slightly in the future to compensate for client clock drift.
+	MAX_PLAYED_AT_DRIFT = 5 * time.Minute
+)
+
+type ImportError struct {
+	Row     int    `json:"row"`
+	Game    string `json:"game"`
+	Message string `json:"message"`
+}
+
+type ImportResponse struct {
+	Message string        `json:"message"`
+	GameIds []string      `json:"gameids"`
+	Errors  []ImportError `json:"errors,omitempty"`
+}
+
+func ImportHandler(dynamoClient *dynamodb.Client) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		response, code, err := runImportHandler(dynamoClient, &request, ctx)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: code,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       err.Error(),
+			}, nil
+		}
+		serializedResponse, err := json.Marshal(&response)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: http.StatusInternalServerError,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       "failed to serialize response",
+			}, nil
+		}
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode: code,
+			Headers:    map[string]string{"Content-Type": "application/json"},
+			Body:       string(serializedResponse),
+		}, nil
+	}
+}
+
+func runImportHandler(dynamoClient *dynamodb.Client, request *events.APIGatewayV2HTTPRequest, ctx context.Context) (*ImportResponse, int, error) {
+	claims := request.RequestContext.Authorizer.JWT.Claims
+	sub := claims["sub"]
+	if sub == "" {
+		return nil, http.StatusUnprocessableEntity, fmt.Errorf("invalid sub claim in the ID token")
+	}
+
+	isAdmin := auth.IsGroupMember(claims, ADMINGROUP)
+	if !isAdmin && !auth.IsGroupMember(claims, REFEREEGROUP) {
+		return nil, http.StatusForbidden, fmt.Errorf("only admins and referees are allowed to import games")
+	}
+
+	preconfirmed := request.QueryStringParameters["preconfirmed"] == "true"
+	if preconfirmed && !isAdmin {
+		return nil, http.StatusForbidden, fmt.Errorf("only admins are allowed to import preconfirmed games")
+	}
+
+	body := []byte(request.Body)
+	if request.IsBase64Encoded {
+		var err error
+		body, err = base64.StdEncoding.DecodeString(request.Body)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("failed to decode request body")
+		}
+	}
+
+	var games []parse.GameRequest
+	var rowErrors []parse.RowError
+	var err error
+	if strings.HasPrefix(request.Headers["content-type"], "text/csv") {
+		games, rowErrors, err = parse.ParseCSV(bytes.NewReader(body))
+	} else {
+		games, err = parse.ParseJSON(bytes.NewReader(body))
+	}
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to deserialize request: %v", err)
+	}
+	if len(rowErrors) > 0 {
+		parseErrors := []ImportError{}
+		for _, rowError := range rowErrors {
+			parseErrors = append(parseErrors, ImportError{
+				Row: rowError.Row, Game: rowError.Game, Message: rowError.Message,
+			})
+		}
+		return &ImportResponse{
+			Message: "failed to parse import. no game was imported",
+			GameIds: []string{},
+			Errors:  parseErrors,
+		}, http.StatusBadRequest, nil
+	}
+
+	if len(games) < 1 {
+		return nil, http.StatusBadRequest, fmt.Errorf("minimum number of games is 1")
+	}
+
+	if len(games) > MAXIMUM_GAMES {
+		return nil, http.StatusBadRequest, fmt.Errorf("maximum number of games is %d", MAXIMUM_GAMES)
+	}
+
+	// all games are validated before any game is inserted.
+	users := map[string]*query.UserOutput{}
+	validationErrors := []ImportError{}
+	playedAtTimes := make([]time.Time, len(games))
+	for i, game := range games {
+		addError := func(row int, format string, a ...any) {
+			validationErrors = append(validationErrors, ImportError{
+				Row: row, Game: game.Ref, Message: fmt.Sprintf(format, a...),
+			})
+		}
+
+		if game.QuorumPolicy != "" && !quorum.IsValid(game.QuorumPolicy) {
+			addError(game.Row, "invalid quorum policy: %s", game.QuorumPolicy)
+		}
+		if len(game.Name) > MAX_NAME_LENGTH {
+			addError(game.Row, "maximum name length is %d", MAX_NAME_LENGTH)
+		}
+		if len(game.Location) > MAX_LOCATION_LENGTH {
+			addError(game.Row, "maximum location length is %d", MAX_LOCATION_LENGTH)
+		}
+		if len(game.Notes) > MAX_NOTES_LENGTH {
+			addError(game.Row, "maximum notes length is %d", MAX_NOTES_LENGTH)
+		}
+
+		playedAtTimes[i] = time.Now().UTC()
+		if game.PlayedAt != "" {
+			playedAt, err := time.Parse(time.RFC3339, game.PlayedAt)
+			if err != nil {
+				addError(game.Row, "invalid played_at timestamp: expected RFC3339 format with timezone")
+			} else if playedAt.After(time.Now().Add(MAX_PLAYED_AT_DRIFT)) {
+				addError(game.Row, "played_at timestamp must not be in the future")
+			} else if playedAt.Before(time.Now().Add(-time.Duration(MAX_PLAYED_AT_AGE_DAYS) * 24 * time.Hour)) {
+				addError(game.Row, "played_at timestamp must not be older than %d days", MAX_PLAYED_AT_AGE_DAYS)
+			}
+			playedAtTimes[i] = playedAt
+		}
+
+		if len(game.Participants) < 2 {
+			addError(game.Row, "minimum number of participants is 2")
+		}
+		if len(game.Participants) > MAXIMUM_PARTICIPANTS {
+			addError(game.Row, "maximum number of participants is %d", MAXIMUM_PARTICIPANTS)
+		}
+
+		gameUsernames := map[string]bool{}
+		for _, part := range game.Participants {
+			if gameUsernames[part.Username] {
+				addError(part.Row, "participant: %s found twice", part.Username)
+				continue
+			}
+			gameUsernames[part.Username] = true
+
+			if _, ok := users[part.Username]; ok {
+				continue
+			}
+			user, err := query.FetchByUsername(dynamoClient, ctx, USERTABLE, part.Username)
+			if err != nil {
+				addError(part.Row, "failed to lookup %s: %v", part.Username, err)
+				continue
+			}
+			if user.Disabled {
+				addError(part.Row, "failed to lookup %s: user is disabled", part.Username)
+				continue
+			}
+			users[part.Username] = user
+		}
+	}
+	if len(validationErrors) > 0 {
+		return &ImportResponse{
+			Message: "failed to validate import. no game was imported",
+			GameIds: []string{},
+			Errors:  validationErrors,
+		}, http.StatusBadRequest, nil
+	}
+
+	// elo is tracked locally, so that preconfirmed games are rated based on the results of the previous games.
+	userElo := map[string]int{}
+	for _, user := range users {
+		userElo[user.Subject] = user.Elo
+	}
+
+	gameIds := []string{}
+	importErrors := []ImportError{}
+	emailImportRequests := map[string]*outbox.EmailImportRequest{}
+	// mail jobs are held until the import is completed, the hold outlasts the handler timeout,
+	// therefore the jobs are sent even if the handler is aborted.
+	importId := put.NewGameId()
+	holdUntil := int(time.Now().Add(time.Duration(MAIL_HOLD_SECONDS) * time.Second).Unix())
+	for i, game := range games {
+		ratingInputParticipants := []rating.ParticipantInput{}
+		for _, part := range game.Participants {
+			user := users[part.Username]
+			ratingInputParticipants = append(ratingInputParticipants, rating.ParticipantInput{
+				UserRef:   user,
+				Team:      part.Team,
+				Rating:    userElo[user.Subject],
+				Points:    part.Points,
+				Placement: part.Placement,
+			})
+		}
+		ratingOutputParticipants := rating.CalculateRatingUpdate(ratingInputParticipants, game.PlacementPoints, MAX_LOSS_NUMBER)
+
+		gameMetadata := put.MetadataInput{
+			Name:     game.Name,
+			Location: game.Location,
+			Notes:    game.Notes,
+			PlayedAt: playedAtTimes[i],
+		}
+
+		gameInputParticipants := map[string]put.ParticipantInput{}
+		gameConfirmRequests := map[string]outbox.GameConfirmRequest{}
+		for _, part := range ratingOutputParticipants {
+			base64Secret := ""
+			if !preconfirmed {
+				secret := make([]byte, CONFIRM_SECRET_LENGTH)
+				if _, err := rand.Read(secret); err != nil {
+					return nil, http.StatusInternalServerError, fmt.Errorf("failed to generate confirmation secret")
+				}
+				base64Secret = base64.RawURLEncoding.EncodeToString(secret)
+			}
+
+			gameConfirmRequests[part.UserRef.Username] = outbox.GameConfirmRequest{
+				Username:  part.UserRef.Username,
+				Name:      game.Name,
+				PlayedAt:  playedAtTimes[i].Format(time.RFC1123),
+				Secret:    base64Secret,
+				Placement: part.Placement,
+				Points:    part.Points,
+				EloUpdate: part.RatingUpdate,
+			}
+
+			gameInputParticipants[part.UserRef.Subject] = put.ParticipantInput{
+				Subject:       part.UserRef.Subject,
+				Username:      part.UserRef.Username,
+				Underdog:      part.Underdog,
+				Team:          part.Team,
+				Placement:     part.Placement,
+				Points:        part.Points,
+				Elo:           part.Rating,
+				EloUpdate:     part.RatingUpdate,
+				Confirmed:     false,
+				ConfirmSecret: base64Secret,
+			}
+		}
+
+		if preconfirmed {
+			gameid, err := put.InsertConfirmedGame(dynamoClient, ctx, USERTABLE, GAMETABLE, PARTICIPATIONTABLE, sub, &gameMetadata, gameInputParticipants)
+			if err != nil {
+				// games are inserted in order, the import is aborted on the first failure.
+				importErrors = append(importErrors, ImportError{
+					Row: game.Row, Game: game.Ref, Message: fmt.Sprintf("failed to insert game: %v", err),
+				})
+				break
+			}
+			gameIds = append(gameIds, gameid)
+			for _, part := range ratingOutputParticipants {
+				userElo[part.UserRef.Subject] += part.RatingUpdate
+			}
+			continue
+		}
+
+		gameid := put.NewGameId()
+		mailJobs := []put.MailJobInput{}
+		for username, confirmRequest := range gameConfirmRequests {
+			confirmRequest.GameId = gameid
+			emailRequest := outbox.EmailImportRequest{
+				Subject:  users[username].Subject,
+				Username: username,
+				Email:    users[username].Email,
+			}
+			if previousRequest, ok := emailImportRequests[username]; ok {
+				emailRequest.Games = append(emailRequest.Games, previousRequest.Games...)
+			}
+			emailRequest.Games = append(emailRequest.Games, confirmRequest)
+			mailJob, err := outbox.BuildImportJob(MAILTEMPLATE, importId, holdUntil, &emailRequest)
+			if err != nil {
+				return nil, http.StatusInternalServerError, fmt.Errorf("failed to build confirmation mails: %v", err)
+			}
+			mailJobs = append(mailJobs, mailJob)
+			// the request is recorded for the jobs of the following games once the game is inserted.
+			gameConfirmRequests[username] = confirmRequest
+		}
+
+		for key, part := range gameInputParticipants {
+			part.MailStatus = "queued"
+			gameInputParticipants[key] = part
+		}
+
+		expirationTime := time.Now().Add(time.Duration(HOURS_UNTIL_EXPIRED) * time.Hour)
+		err := put.InsertGame(dynamoClient, ctx, GAMETABLE, PARTICIPATIONTABLE, OUTBOXTABLE, gameid, sub, game.QuorumPolicy, &gameMetadata, gameInputParticipants, int(expirationTime.Unix()), mailJobs)
+		if err != nil {
+			// games are inserted in order, the import is aborted on the first failure.
+			importErrors = append(importErrors, ImportError{
+				Row: game.Row, Game: game.Ref, Message: fmt.Sprintf("failed to insert game: %v", err),
+			})
+			break
+		}
+		gameIds = append(gameIds, gameid)
+
+		for username, confirmRequest := range gameConfirmRequests {
+			emailRequest, ok := emailImportRequests[username]
+			if !ok {
+				emailRequest = &outbox.EmailImportRequest{}
+				emailImportRequests[username] = emailRequest
+			}
+			emailRequest.Games = append(emailRequest.Games, confirmRequest)
+		}
+	}
+
+	if len(emailImportRequests) > 0 {
+		jobIds := []string{}
+		for username := range emailImportRequests {
+			jobIds = append(jobIds, fmt.Sprintf("%s#%s", importId, users[username].Subject))
+		}
+		// releasing the jobs is an optimization, held jobs are sent after the hold anyway.
+		if err := put.ReleaseMailJobs(dynamoClient, ctx, OUTBOXTABLE, jobIds); err != nil {
+			log.Printf("WARNING: failed to release mail jobs of import %s: %v\n", importId, err)
+		}
+	}
+
+	if len(importErrors) > 0 {
+		return &ImportResponse{
+			Message: fmt.Sprintf("imported %d of %d games before an error occurred", len(gameIds), len(games)),
+			GameIds: gameIds,
+			Errors:  importErrors,
+		}, http.StatusInternalServerError, nil
+	}
+
+	if preconfirmed {
+		return &ImportResponse{
+			Message: fmt.Sprintf("successfully imported %d preconfirmed games", len(gameIds)),
+			GameIds: gameIds,
+		}, http.StatusOK, nil
+	}
+	return &ImportResponse{
+		Message: fmt.Sprintf("successfully imported %d games. ensure all players confirm the games to validate them", len(gameIds)),
+		GameIds: gameIds,
+	}, http.StatusOK, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+var (
+	REGION                 = os.Getenv("AWS_REGION")
+	USERTABLE              = os.Getenv("USERTABLE")
+	GAMETABLE              = os.Getenv("GAMETABLE")
+	PARTICIPATIONTABLE     = os.Getenv("PARTICIPATIONTABLE")
+	MAILTEMPLATE           = os.Getenv("MAILTEMPLATE")
+	OUTBOXTABLE            = os.Getenv("OUTBOXTABLE")
+	ADMINGROUP             = os.Getenv("ADMINGROUP")
+	REFEREEGROUP           = os.Getenv("REFEREEGROUP")
+	CONFIRM_SECRET_LENGTH  = 20 // default 20
+	HOURS_UNTIL_EXPIRED    = 24 // default 24
+	MAXIMUM_PARTICIPANTS   = 40 // default 40
+	MAX_LOSS_NUMBER        = 40 // default 40
+	MAX_PLAYED_AT_AGE_DAYS = 30 // default 30
+	MAXIMUM_GAMES          = 50 // default 50
+	MAIL_HOLD_SECONDS      = 60 // default 60
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatalf("ERROR INITIALIZATION: %v\n", err)
+	}
+}
+
+func run() error {
+	awsConfig, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(REGION))
+	if err != nil {
+		return fmt.Errorf("failed to load aws config: %v", err)
+	}
+	dynamoClient := dynamodb.NewFromConfig(awsConfig)
+
+	if secretLength, err := strconv.Atoi(os.Getenv("CONFIRM_SECRET_LENGTH")); err == nil {
+		CONFIRM_SECRET_LENGTH = secretLength
+	}
+	if hoursUntilExpired, err := strconv.Atoi(os.Getenv("HOURS_UNTIL_EXPIRED")); err == nil {
+		HOURS_UNTIL_EXPIRED = hoursUntilExpired
+	}
+	if maximumParticipants, err := strconv.Atoi(os.Getenv("MAXIMUM_PARTICIPANTS")); err == nil {
+		MAXIMUM_PARTICIPANTS = maximumParticipants
+	}
+	if maxLossNumber, err := strconv.Atoi(os.Getenv("MAX_LOSS_NUMBER")); err == nil {
+		MAX_LOSS_NUMBER = maxLossNumber
+	}
+	if maxPlayedAtAgeDays, err := strconv.Atoi(os.Getenv("MAX_PLAYED_AT_AGE_DAYS")); err == nil {
+		MAX_PLAYED_AT_AGE_DAYS = maxPlayedAtAgeDays
+	}
+	if maximumGames, err := strconv.Atoi(os.Getenv("MAXIMUM_GAMES")); err == nil {
+		MAXIMUM_GAMES = maximumGames
+	}
+	if mailHoldSeconds, err := strconv.Atoi(os.Getenv("MAIL_HOLD_SECONDS")); err == nil {
+		MAIL_HOLD_SECONDS = mailHoldSeconds
+	}
+
+	lambda.Start(ImportHandler(dynamoClient))
+	return nil
+}
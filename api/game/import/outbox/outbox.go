@@ -0,0 +1,61 @@
+// contains helpers to build the grouped confirmation mail jobs of the outbox.
+// the jobs are written together with the imported games and delivered by the mailer.
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/megakuul/leaderboard/api/game/import/put"
+)
+
+type GameConfirmRequest struct {
+	GameId    string `json:"gameid"`
+	Username  string `json:"username"`
+	Name      string `json:"name"`
+	PlayedAt  string `json:"played_at"`
+	Secret    string `json:"secret"`
+	Placement int    `json:"placement"`
+	Points    int    `json:"points"`
+	EloUpdate int    `json:"elo_update"`
+}
+
+type EmailImportRequest struct {
+	Subject  string
+	Username string
+	Email    string
+	Games    []GameConfirmRequest
+}
+
+type emailTemplateInput struct {
+	Username string               `json:"username"`
+	Games    []GameConfirmRequest `json:"games"`
+}
+
+// BuildImportJob creates the mail job containing the confirmation links of all games imported for the user so far.
+// the job is rewritten with every inserted game, it is held until the specified unix time,
+// so that the user receives one mail per import.
+func BuildImportJob(mailTemplate, importId string, holdUntil int, request *EmailImportRequest) (put.MailJobInput, error) {
+	templateInput := emailTemplateInput{
+		Username: request.Username,
+		Games:    request.Games,
+	}
+	templateInputSerialized, err := json.Marshal(&templateInput)
+	if err != nil {
+		return put.MailJobInput{}, fmt.Errorf("failed to serialize mail input")
+	}
+	gameIds := []string{}
+	for _, game := range request.Games {
+		gameIds = append(gameIds, game.GameId)
+	}
+	return put.MailJobInput{
+		JobId:         fmt.Sprintf("%s#%s", importId, request.Subject),
+		GameId:        gameIds[len(gameIds)-1],
+		GameIds:       gameIds,
+		Participant:   request.Subject,
+		Email:         request.Email,
+		Template:      mailTemplate,
+		TemplateData:  string(templateInputSerialized),
+		NextAttemptAt: holdUntil,
+	}, nil
+}
@@ -0,0 +1,157 @@
+// contains parsers for the supported import formats.
+// every parsed entity remembers the row it originates from,
+// this allows the handler to report errors per row.
+package parse
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type ParticipantRequest struct {
+	Row       int    `json:"-"`
+	Username  string `json:"username"`
+	Team      int    `json:"team"`
+	Points    int    `json:"points"`
+	Placement int    `json:"placement"`
+}
+
+type GameRequest struct {
+	Row             int                  `json:"-"`
+	Ref             string               `json:"ref"`
+	PlacementPoints int                  `json:"placement_points"`
+	QuorumPolicy    string               `json:"quorum_policy"`
+	Name            string               `json:"name"`
+	Location        string               `json:"location"`
+	Notes           string               `json:"notes"`
+	PlayedAt        string               `json:"played_at"`
+	Participants    []ParticipantRequest `json:"participants"`
+}
+
+// ParseJSON parses a json array of games.
+// the row of a game and its participants is the 1-based index of the game in the array.
+func ParseJSON(input io.Reader) ([]GameRequest, error) {
+	var games []GameRequest
+	if err := json.NewDecoder(input).Decode(&games); err != nil {
+		return nil, fmt.Errorf("invalid json array of games")
+	}
+	for i := range games {
+		games[i].Row = i + 1
+		if games[i].Ref == "" {
+			games[i].Ref = strconv.Itoa(i + 1)
+		}
+		for j := range games[i].Participants {
+			games[i].Participants[j].Row = i + 1
+		}
+	}
+	return games, nil
+}
+
+// RowError describes an invalid row of the input.
+type RowError struct {
+	Row     int
+	Game    string
+	Message string
+}
+
+// ParseCSV parses a csv file with one participant per row.
+// rows are grouped into games by the "game" column, game level columns (e.g. "name")
+// are taken from the first row of the game. the header row is row 1.
+// invalid rows are skipped and reported as row errors, so that all rows are checked at once.
+func ParseCSV(input io.Reader) ([]GameRequest, []RowError, error) {
+	reader := csv.NewReader(input)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read csv header")
+	}
+	columns := map[string]int{}
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	for _, required := range []string{"game", "username", "team", "placement", "points"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("missing required csv column '%s'", required)
+		}
+	}
+
+	games := []GameRequest{}
+	gameIndex := map[string]int{}
+	rowErrors := []RowError{}
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, nil, fmt.Errorf("row %d: %v", row, err)
+			}
+			rowErrors = append(rowErrors, RowError{Row: row, Message: parseErr.Err.Error()})
+			continue
+		}
+		field := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		ref := field("game")
+		addError := func(format string, a ...any) {
+			rowErrors = append(rowErrors, RowError{Row: row, Game: ref, Message: fmt.Sprintf(format, a...)})
+		}
+		number := func(column string) int {
+			value := field(column)
+			if value == "" {
+				return 0
+			}
+			parsedValue, err := strconv.Atoi(value)
+			if err != nil {
+				addError("column '%s' must be a number", column)
+				return 0
+			}
+			return parsedValue
+		}
+
+		rowErrorCount := len(rowErrors)
+		participant := ParticipantRequest{
+			Row:       row,
+			Username:  field("username"),
+			Team:      number("team"),
+			Placement: number("placement"),
+			Points:    number("points"),
+		}
+		if ref == "" {
+			addError("column 'game' must not be empty")
+		}
+		i, ok := gameIndex[ref]
+		placementPoints := 0
+		if !ok {
+			placementPoints = number("placement_points")
+		}
+		if len(rowErrors) > rowErrorCount {
+			continue
+		}
+		if !ok {
+			games = append(games, GameRequest{
+				Row:             row,
+				Ref:             ref,
+				PlacementPoints: placementPoints,
+				QuorumPolicy:    field("quorum_policy"),
+				Name:            field("name"),
+				Location:        field("location"),
+				Notes:           field("notes"),
+				PlayedAt:        field("played_at"),
+			})
+			i = len(games) - 1
+			gameIndex[ref] = i
+		}
+		games[i].Participants = append(games[i].Participants, participant)
+	}
+	return games, rowErrors, nil
+}
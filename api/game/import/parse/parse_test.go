@@ -0,0 +1,149 @@
+package parse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantGames []GameRequest
+		wantRows  []RowError
+		wantErr   bool
+	}{
+		{
+			name: "groups rows by game",
+			input: "game,username,team,placement,points,name,placement_points\n" +
+				"g1,alice,1,1,10,Finals,5\n" +
+				"g1,bob,2,2,4,ignored,9\n" +
+				"g2,carol,1,1,3,,\n",
+			wantGames: []GameRequest{
+				{Row: 2, Ref: "g1", Name: "Finals", PlacementPoints: 5, Participants: []ParticipantRequest{
+					{Row: 2, Username: "alice", Team: 1, Placement: 1, Points: 10},
+					{Row: 3, Username: "bob", Team: 2, Placement: 2, Points: 4},
+				}},
+				{Row: 4, Ref: "g2", Participants: []ParticipantRequest{
+					{Row: 4, Username: "carol", Team: 1, Placement: 1, Points: 3},
+				}},
+			},
+			wantRows: []RowError{},
+		},
+		{
+			name: "header is case insensitive and trimmed",
+			input: " Game , USERNAME,Team,Placement,Points\n" +
+				"g1, alice ,1,1,0\n",
+			wantGames: []GameRequest{
+				{Row: 2, Ref: "g1", Participants: []ParticipantRequest{
+					{Row: 2, Username: "alice", Team: 1, Placement: 1},
+				}},
+			},
+			wantRows: []RowError{},
+		},
+		{
+			name: "reports invalid rows and keeps valid ones",
+			input: "game,username,team,placement,points\n" +
+				"g1,alice,one,1,0\n" +
+				",bob,1,1,0\n" +
+				"g1,carol,1,1,0\n",
+			wantGames: []GameRequest{
+				{Row: 4, Ref: "g1", Participants: []ParticipantRequest{
+					{Row: 4, Username: "carol", Team: 1, Placement: 1},
+				}},
+			},
+			wantRows: []RowError{
+				{Row: 2, Game: "g1", Message: "column 'team' must be a number"},
+				{Row: 3, Message: "column 'game' must not be empty"},
+			},
+		},
+		{
+			name: "reports every invalid column of a row",
+			input: "game,username,team,placement,points\n" +
+				"g1,alice,x,y,0\n",
+			wantGames: []GameRequest{},
+			wantRows: []RowError{
+				{Row: 2, Game: "g1", Message: "column 'team' must be a number"},
+				{Row: 2, Game: "g1", Message: "column 'placement' must be a number"},
+			},
+		},
+		{
+			name: "reports rows with a wrong number of fields",
+			input: "game,username,team,placement,points\n" +
+				"g1,alice,1\n" +
+				"g1,bob,1,1,0\n",
+			wantGames: []GameRequest{
+				{Row: 3, Ref: "g1", Participants: []ParticipantRequest{
+					{Row: 3, Username: "bob", Team: 1, Placement: 1},
+				}},
+			},
+			wantRows: []RowError{
+				{Row: 2, Message: "wrong number of fields"},
+			},
+		},
+		{
+			name:    "missing required column",
+			input:   "game,username,team,placement\ng1,alice,1,1\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty input",
+			input:   "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			games, rowErrors, err := ParseCSV(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCSV() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(games, tt.wantGames) {
+				t.Errorf("ParseCSV() games = %+v, want %+v", games, tt.wantGames)
+			}
+			if !reflect.DeepEqual(rowErrors, tt.wantRows) {
+				t.Errorf("ParseCSV() row errors = %+v, want %+v", rowErrors, tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []GameRequest
+		wantErr bool
+	}{
+		{
+			name:  "assigns rows and default refs",
+			input: `[{"participants":[{"username":"alice","team":1,"placement":1}]},{"ref":"final"}]`,
+			want: []GameRequest{
+				{Row: 1, Ref: "1", Participants: []ParticipantRequest{
+					{Row: 1, Username: "alice", Team: 1, Placement: 1},
+				}},
+				{Row: 2, Ref: "final"},
+			},
+		},
+		{
+			name:    "rejects non array input",
+			input:   `{"ref":"final"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			games, err := ParseJSON(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(games, tt.want) {
+				t.Errorf("ParseJSON() = %+v, want %+v", games, tt.want)
+			}
+		})
+	}
+}
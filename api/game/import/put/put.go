@@ -0,0 +1,267 @@
+// contains wrappers for database put functions.
+// main purpose is to abstract some boilerplate code
+// away from the handler.
+package put
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+)
+
+type ParticipantInput struct {
+	Subject       string `dynamodbav:"subject"`
+	Username      string `dynamodbav:"username"`
+	Underdog      bool   `dynamodbav:"underdog"`
+	Team          int    `dynamodbav:"team"`
+	Placement     int    `dynamodbav:"placement"`
+	Points        int    `dynamodbav:"points"`
+	Elo           int    `dynamodbav:"elo"`
+	EloUpdate     int    `dynamodbav:"elo_update"`
+	Confirmed     bool   `dynamodbav:"confirmed"`
+	ConfirmSecret string `dynamodbav:"confirm_secret,omitempty"`
+	MailStatus    string `dynamodbav:"mail_status,omitempty"`
+}
+
+type MetadataInput struct {
+	Name     string
+	Location string
+	Notes    string
+	PlayedAt time.Time
+}
+
+type GameInput struct {
+	GameId       string                      `dynamodbav:"gameid"`
+	Date         string                      `dynamodbav:"game_date"`
+	PlayedAt     string                      `dynamodbav:"played_at"`
+	Name         string                      `dynamodbav:"name,omitempty"`
+	Location     string                      `dynamodbav:"location,omitempty"`
+	Notes        string                      `dynamodbav:"notes,omitempty"`
+	ExpiresIn    int                         `dynamodbav:"expires_in,omitempty"`
+	Readonly     bool                        `dynamodbav:"readonly"`
+	Pending      string                      `dynamodbav:"pending,omitempty"`
+	Submitter    string                      `dynamodbav:"submitter"`
+	QuorumPolicy string                      `dynamodbav:"quorum_policy,omitempty"`
+	Imported     bool                        `dynamodbav:"imported"`
+	Partcipants  map[string]ParticipantInput `dynamodbav:"participants"`
+}
+
+func newGameInput(submitter, quorumPolicy string, metadata *MetadataInput, participants map[string]ParticipantInput) *GameInput {
+	// the date is derived in the timezone the game was played in.
+	return &GameInput{
+		GameId:       uuid.New().String(),
+		Date:         metadata.PlayedAt.Format("2006-01-02"),
+		PlayedAt:     metadata.PlayedAt.Format(time.RFC3339),
+		Name:         metadata.Name,
+		Location:     metadata.Location,
+		Notes:        metadata.Notes,
+		Readonly:     false,
+		Submitter:    submitter,
+		QuorumPolicy: quorumPolicy,
+		Imported:     true,
+		Partcipants:  participants,
+	}
+}
+
+// ParticipationInput is an entry of the participant index. It references a game of the participant,
+// participations are sorted by the time the game was played (in utc).
+type ParticipationInput struct {
+	Subject  string `dynamodbav:"subject"`
+	SortKey  string `dynamodbav:"played_at_gameid"`
+	GameId   string `dynamodbav:"gameid"`
+	Username string `dynamodbav:"username"`
+	Date     string `dynamodbav:"game_date"`
+}
+
+// MailJobInput is a confirmation mail job of the outbox, it is delivered by the mailer.
+// import jobs refer to all games of the import that were inserted for the participant.
+type MailJobInput struct {
+	JobId         string   `dynamodbav:"jobid"`
+	GameId        string   `dynamodbav:"gameid"`
+	GameIds       []string `dynamodbav:"gameids"`
+	Participant   string   `dynamodbav:"participant"`
+	Email         string   `dynamodbav:"email"`
+	Template      string   `dynamodbav:"template"`
+	TemplateData  string   `dynamodbav:"template_data"`
+	Attempts      int      `dynamodbav:"attempts"`
+	NextAttemptAt int      `dynamodbav:"next_attempt_at"`
+	Status        string   `dynamodbav:"status"`
+	Queue         string   `dynamodbav:"queue"`
+}
+
+// newParticipationItems creates the participation puts of all participants of the game.
+func newParticipationItems(tableName string, gameInput *GameInput, metadata *MetadataInput) ([]types.TransactWriteItem, error) {
+	transactItems := []types.TransactWriteItem{}
+	for _, part := range gameInput.Partcipants {
+		participationSerialized, err := attributevalue.MarshalMap(&ParticipationInput{
+			Subject:  part.Subject,
+			SortKey:  fmt.Sprintf("%s#%s", metadata.PlayedAt.UTC().Format(time.RFC3339), gameInput.GameId),
+			GameId:   gameInput.GameId,
+			Username: part.Username,
+			Date:     gameInput.Date,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize participation")
+		}
+		transactItems = append(transactItems, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName: aws.String(tableName),
+				Item:      participationSerialized,
+			},
+		})
+	}
+	return transactItems, nil
+}
+
+// NewGameId generates the id of a game before it is inserted, this allows referencing it in the mail jobs.
+func NewGameId() string {
+	return uuid.New().String()
+}
+
+// InsertGame inserts a pending game that must be confirmed by the participants.
+// the game, the participations and the mail jobs of the participants are written in one transaction.
+// the mail jobs replace the jobs that were written with the previous games of the import.
+func InsertGame(dynamoClient *dynamodb.Client, ctx context.Context, gameTableName, participationTableName, outboxTableName, gameId, submitter, quorumPolicy string, metadata *MetadataInput, participants map[string]ParticipantInput, expirationTime int, mailJobs []MailJobInput) error {
+	gameInput := newGameInput(submitter, quorumPolicy, metadata, participants)
+	gameInput.GameId = gameId
+	gameInput.ExpiresIn = expirationTime
+	// pending games are indexed in the sparse pending_gsi until they are finalized.
+	gameInput.Pending = "true"
+
+	gameInputSerialized, err := attributevalue.MarshalMap(gameInput)
+	if err != nil {
+		return fmt.Errorf("failed to serialize put input")
+	}
+
+	participationItems, err := newParticipationItems(participationTableName, gameInput, metadata)
+	if err != nil {
+		return err
+	}
+
+	transactItems := append([]types.TransactWriteItem{{
+		Put: &types.Put{
+			TableName: aws.String(gameTableName),
+			Item:      gameInputSerialized,
+		},
+	}}, participationItems...)
+
+	for _, mailJob := range mailJobs {
+		mailJob.Attempts = 0
+		mailJob.Status = "queued"
+		mailJob.Queue = "pending"
+		mailJobSerialized, err := attributevalue.MarshalMap(&mailJob)
+		if err != nil {
+			return fmt.Errorf("failed to serialize mail job")
+		}
+		transactItems = append(transactItems, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName: aws.String(outboxTableName),
+				Item:      mailJobSerialized,
+			},
+		})
+	}
+
+	_, err = dynamoClient.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// InsertConfirmedGame inserts a finalized game and distributes the elo to the participants.
+// the game, the participations and the user updates are written in one transaction.
+func InsertConfirmedGame(dynamoClient *dynamodb.Client, ctx context.Context, userTableName, gameTableName, participationTableName, submitter string, metadata *MetadataInput, participants map[string]ParticipantInput) (string, error) {
+	for key, part := range participants {
+		part.Confirmed = true
+		part.ConfirmSecret = ""
+		participants[key] = part
+	}
+	gameInput := newGameInput(submitter, "", metadata, participants)
+	gameInput.Readonly = true
+
+	gameInputSerialized, err := attributevalue.MarshalMap(gameInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize put input")
+	}
+
+	participationItems, err := newParticipationItems(participationTableName, gameInput, metadata)
+	if err != nil {
+		return "", err
+	}
+
+	transactItems := append([]types.TransactWriteItem{{
+		Put: &types.Put{
+			TableName: aws.String(gameTableName),
+			Item:      gameInputSerialized,
+		},
+	}}, participationItems...)
+	for _, part := range participants {
+		transactItems = append(transactItems, types.TransactWriteItem{
+			Update: &types.Update{
+				TableName: aws.String(userTableName),
+				Key: map[string]types.AttributeValue{
+					"subject": &types.AttributeValueMemberS{Value: part.Subject},
+				},
+				ExpressionAttributeNames: map[string]string{
+					"#elo": "elo",
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":elo_update": &types.AttributeValueMemberN{Value: strconv.Itoa(part.EloUpdate)},
+				},
+				ConditionExpression: aws.String("attribute_exists(subject)"), // prevent it to upsert if not existent
+				UpdateExpression:    aws.String("ADD #elo :elo_update"),
+			},
+		})
+	}
+
+	_, err = dynamoClient.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return gameInput.GameId, nil
+}
+
+// ReleaseMailJobs makes the held mail jobs due immediately.
+// jobs that are already claimed by the mailer are skipped.
+func ReleaseMailJobs(dynamoClient *dynamodb.Client, ctx context.Context, outboxTableName string, jobIds []string) error {
+	now := strconv.Itoa(int(time.Now().Unix()))
+	for _, jobId := range jobIds {
+		_, err := dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName: aws.String(outboxTableName),
+			Key: map[string]types.AttributeValue{
+				"jobid": &types.AttributeValueMemberS{Value: jobId},
+			},
+			ExpressionAttributeNames: map[string]string{
+				"#queue": "queue",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":now":      &types.AttributeValueMemberN{Value: now},
+				":attempts": &types.AttributeValueMemberN{Value: "0"},
+			},
+			ConditionExpression: aws.String("attribute_exists(#queue) AND attempts = :attempts AND next_attempt_at > :now"),
+			UpdateExpression:    aws.String("SET next_attempt_at = :now"),
+		})
+		if err != nil {
+			var condErr *types.ConditionalCheckFailedException
+			if errors.As(err, &condErr) {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
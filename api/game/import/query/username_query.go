@@ -0,0 +1,40 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// FetchByUsername fetches the user with the specified username.
+// usernames that are shared by multiple users (created before usernames were reserved) are rejected as ambiguous.
+func FetchByUsername(dynamoClient *dynamodb.Client, ctx context.Context, tableName string, username string) (*UserOutput, error) {
+	output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
+		TableName: aws.String(tableName),
+		IndexName: aws.String("username_gsi"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":username": &types.AttributeValueMemberS{Value: username},
+		},
+		KeyConditionExpression: aws.String("username = :username"),
+		Limit:                  aws.Int32(2),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var users []UserOutput
+	err = attributevalue.UnmarshalListOfMaps(output.Items, &users)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) < 1 {
+		return nil, fmt.Errorf("user not found")
+	}
+	if len(users) > 1 {
+		return nil, fmt.Errorf("username is ambiguous")
+	}
+	return &users[0], nil
+}
@@ -0,0 +1,26 @@
+// contains the quorum policies that decide
+// when a game has enough confirmations to be finalized.
+// the policies are evaluated by the confirmation handler (api/game/confirm/quorum),
+// this package only validates them on submission and must list the same policies.
+package quorum
+
+const (
+	// every participant must confirm the game.
+	POLICY_ALL = "all"
+	// more than half of the participants must confirm the game.
+	POLICY_MAJORITY = "majority"
+	// at least one participant of every team must confirm the game.
+	POLICY_TEAM = "team"
+	// the submitter and at least one participant of another team must confirm the game.
+	POLICY_OPPONENT = "opponent"
+)
+
+// IsValid checks if the provided policy is a known quorum policy.
+func IsValid(policy string) bool {
+	switch policy {
+	case POLICY_ALL, POLICY_MAJORITY, POLICY_TEAM, POLICY_OPPONENT:
+		return true
+	default:
+		return false
+	}
+}
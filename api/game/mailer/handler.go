@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"github.com/megakuul/leaderboard/api/game/mailer/queue"
+	"github.com/megakuul/leaderboard/api/game/mailer/sender"
+)
+
+const (
+	MAIL_STATUS_SENT     = "sent"
+	MAIL_STATUS_RETRYING = "retrying"
+	MAIL_STATUS_FAILED   = "failed"
+)
+
+// StatusRecorder records the delivery status of a job on the participant of the game.
+type StatusRecorder func(ctx context.Context, job *queue.Job, status string) error
+
+// MailerHandler processes the due jobs of the outbox. The function is triggered by new outbox records
+// and by a schedule (for retries), the content of the event is irrelevant.
+func MailerHandler(outbox queue.Queue, mailSender sender.Sender, recordStatus StatusRecorder) func(context.Context, json.RawMessage) error {
+	return func(ctx context.Context, event json.RawMessage) error {
+		processed, err := processDueJobs(ctx, outbox, mailSender, recordStatus, time.Now())
+		if err != nil {
+			return err
+		}
+		log.Printf("processed %d jobs\n", processed)
+		return nil
+	}
+}
+
+// processDueJobs processes one batch of due jobs and returns the number of processed jobs.
+func processDueJobs(ctx context.Context, outbox queue.Queue, mailSender sender.Sender, recordStatus StatusRecorder, now time.Time) (int, error) {
+	jobs, err := outbox.Due(ctx, int(now.Unix()), BATCH_SIZE)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch due jobs: %v", err)
+	}
+
+	processed := 0
+	for _, job := range jobs {
+		// the lease prevents other workers from picking up the job while it is sent.
+		// if the worker crashes, the job is due again after the lease expired.
+		leaseUntil := now.Add(time.Duration(LEASE_SECONDS) * time.Second)
+		if err := outbox.Claim(ctx, &job, int(now.Unix()), int(leaseUntil.Unix())); errors.Is(err, queue.ErrJobClaimed) {
+			continue
+		} else if err != nil {
+			log.Printf("ERROR JOB %s: failed to claim job: %v\n", job.JobId, err)
+			continue
+		}
+		processed++
+
+		status, err := processJob(ctx, outbox, mailSender, &job, now)
+		if err != nil {
+			log.Printf("ERROR JOB %s: %v\n", job.JobId, err)
+			continue
+		}
+		if err := recordStatus(ctx, &job, status); err != nil {
+			log.Printf("WARNING JOB %s: failed to record mail status: %v\n", job.JobId, err)
+		}
+	}
+	return processed, nil
+}
+
+// processJob sends a claimed job and returns the resulting mail status of the participant.
+func processJob(ctx context.Context, outbox queue.Queue, mailSender sender.Sender, job *queue.Job, now time.Time) (string, error) {
+	sendErr := mailSender.Send(ctx, job)
+	if sendErr == nil {
+		if err := outbox.Complete(ctx, job); err != nil {
+			return "", fmt.Errorf("failed to complete job: %v", err)
+		}
+		return MAIL_STATUS_SENT, nil
+	}
+
+	if errors.Is(sendErr, sender.ErrPermanent) || job.Attempts >= MAX_ATTEMPTS {
+		log.Printf("WARNING JOB %s: dead-lettering after %d attempts: %v\n", job.JobId, job.Attempts, sendErr)
+		if err := outbox.DeadLetter(ctx, job, sendErr.Error()); err != nil {
+			return "", fmt.Errorf("failed to dead-letter job: %v", err)
+		}
+		return MAIL_STATUS_FAILED, nil
+	}
+
+	nextAttemptAt := now.Add(backoff(job.Attempts))
+	if err := outbox.Retry(ctx, job, int(nextAttemptAt.Unix()), sendErr.Error()); err != nil {
+		return "", fmt.Errorf("failed to reschedule job: %v", err)
+	}
+	return MAIL_STATUS_RETRYING, nil
+}
+
+// backoff calculates the exponential delay before the next attempt (with up to 20% jitter).
+func backoff(attempts int) time.Duration {
+	delay := time.Duration(RETRY_BASE_SECONDS) * time.Second
+	for i := 1; i < attempts && delay < time.Duration(RETRY_MAX_SECONDS)*time.Second; i++ {
+		delay *= 2
+	}
+	delay = min(delay, time.Duration(RETRY_MAX_SECONDS)*time.Second)
+	return delay + time.Duration(rand.Int64N(int64(delay)/5+1))
+}
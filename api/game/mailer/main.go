@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/megakuul/leaderboard/api/game/mailer/queue"
+	"github.com/megakuul/leaderboard/api/game/mailer/sender"
+	"github.com/megakuul/leaderboard/api/game/mailer/update"
+)
+
+var (
+	REGION                = os.Getenv("AWS_REGION")
+	GAMETABLE             = os.Getenv("GAMETABLE")
+	OUTBOXTABLE           = os.Getenv("OUTBOXTABLE")
+	MAILSENDER            = os.Getenv("MAILSENDER")
+	OUTBOX_MODE           = os.Getenv("OUTBOX_MODE")
+	OUTBOX_SEED           = os.Getenv("OUTBOX_SEED")
+	BATCH_SIZE            = 25   // default 25
+	LEASE_SECONDS         = 60   // default 60
+	MAX_ATTEMPTS          = 6    // default 6
+	RETRY_BASE_SECONDS    = 30   // default 30
+	RETRY_MAX_SECONDS     = 3600 // default 3600
+	OUTBOX_RETENTION_DAYS = 14   // default 14
+	LOCAL_FAILED_ATTEMPTS = 0    // default 0
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatalf("ERROR INITIALIZATION: %v\n", err)
+	}
+}
+
+func run() error {
+	if batchSize, err := strconv.Atoi(os.Getenv("BATCH_SIZE")); err == nil {
+		BATCH_SIZE = batchSize
+	}
+	if leaseSeconds, err := strconv.Atoi(os.Getenv("LEASE_SECONDS")); err == nil {
+		LEASE_SECONDS = leaseSeconds
+	}
+	if maxAttempts, err := strconv.Atoi(os.Getenv("MAX_ATTEMPTS")); err == nil {
+		MAX_ATTEMPTS = maxAttempts
+	}
+	if retryBaseSeconds, err := strconv.Atoi(os.Getenv("RETRY_BASE_SECONDS")); err == nil {
+		RETRY_BASE_SECONDS = retryBaseSeconds
+	}
+	if retryMaxSeconds, err := strconv.Atoi(os.Getenv("RETRY_MAX_SECONDS")); err == nil {
+		RETRY_MAX_SECONDS = retryMaxSeconds
+	}
+	if outboxRetentionDays, err := strconv.Atoi(os.Getenv("OUTBOX_RETENTION_DAYS")); err == nil {
+		OUTBOX_RETENTION_DAYS = outboxRetentionDays
+	}
+	if localFailedAttempts, err := strconv.Atoi(os.Getenv("LOCAL_FAILED_ATTEMPTS")); err == nil {
+		LOCAL_FAILED_ATTEMPTS = localFailedAttempts
+	}
+
+	if OUTBOX_MODE == "local" {
+		return runLocal()
+	}
+
+	awsConfig, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(REGION))
+	if err != nil {
+		return fmt.Errorf("failed to load aws config: %v", err)
+	}
+	dynamoClient := dynamodb.NewFromConfig(awsConfig)
+	sesClient := sesv2.NewFromConfig(awsConfig)
+
+	outbox := queue.NewDynamoQueue(dynamoClient, OUTBOXTABLE, OUTBOX_RETENTION_DAYS)
+	recordStatus := func(ctx context.Context, job *queue.Job, status string) error {
+		switch job.Kind {
+		case queue.KIND_EXPIRE:
+			// the game of an expiration mail no longer exists.
+			return nil
+		case queue.KIND_REMIND:
+			// the mail status of the participant refers to the confirmation mail.
+			if status != MAIL_STATUS_SENT {
+				return nil
+			}
+			return update.SetRemindedAt(dynamoClient, ctx, GAMETABLE, job.GameId, job.Participant, int(time.Now().Unix()))
+		}
+		gameIds := job.GameIds
+		if len(gameIds) < 1 {
+			gameIds = []string{job.GameId}
+		}
+		for _, gameId := range gameIds {
+			if err := update.UpdateMailStatus(dynamoClient, ctx, GAMETABLE, gameId, job.Participant, status); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	lambda.Start(MailerHandler(outbox, sender.NewSESSender(sesClient, MAILSENDER), recordStatus))
+	return nil
+}
+
+// runLocal processes the jobs from the OUTBOX_SEED file against an in-memory queue until the queue is drained.
+// mails are printed instead of sent.
+func runLocal() error {
+	jobs := []queue.Job{}
+	if OUTBOX_SEED != "" {
+		seed, err := os.ReadFile(OUTBOX_SEED)
+		if err != nil {
+			return fmt.Errorf("failed to read outbox seed: %v", err)
+		}
+		if err := json.Unmarshal(seed, &jobs); err != nil {
+			return fmt.Errorf("failed to deserialize outbox seed: %v", err)
+		}
+	}
+
+	outbox := queue.NewMemoryQueue(jobs)
+	mailSender := &sender.LogSender{FailAttempts: LOCAL_FAILED_ATTEMPTS}
+	recordStatus := func(ctx context.Context, job *queue.Job, status string) error {
+		log.Printf("STATUS %s: kind=%s game=%s participant=%s mail_status=%s\n", job.JobId, job.Kind, job.GameId, job.Participant, status)
+		return nil
+	}
+
+	ctx := context.Background()
+	for {
+		if _, err := processDueJobs(ctx, outbox, mailSender, recordStatus, time.Now()); err != nil {
+			return err
+		}
+		pending, nextAttemptAt := outbox.Pending()
+		if pending < 1 {
+			break
+		}
+		time.Sleep(max(time.Until(time.Unix(int64(nextAttemptAt), 0)), 100*time.Millisecond))
+	}
+
+	for _, job := range outbox.Jobs() {
+		log.Printf("JOB %s: status=%s attempts=%d last_error=%q\n", job.JobId, job.Status, job.Attempts, job.LastError)
+	}
+	return nil
+}
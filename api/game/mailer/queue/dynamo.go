@@ -0,0 +1,151 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// jobs in the queue carry the "queue" attribute, it is removed once they are sent or dead,
+	// which drops them from the sparse due_gsi.
+	QUEUE_PENDING = "pending"
+)
+
+// DynamoQueue is the outbox queue backed by the outbox table.
+type DynamoQueue struct {
+	client        *dynamodb.Client
+	tableName     string
+	retentionDays int
+}
+
+func NewDynamoQueue(dynamoClient *dynamodb.Client, tableName string, retentionDays int) *DynamoQueue {
+	return &DynamoQueue{
+		client:        dynamoClient,
+		tableName:     tableName,
+		retentionDays: retentionDays,
+	}
+}
+
+func (q *DynamoQueue) Due(ctx context.Context, now, limit int) ([]Job, error) {
+	output, err := q.client.Query(ctx, &dynamodb.QueryInput{
+		TableName: aws.String(q.tableName),
+		IndexName: aws.String("due_gsi"),
+		ExpressionAttributeNames: map[string]string{
+			"#queue": "queue",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":queue": &types.AttributeValueMemberS{Value: QUEUE_PENDING},
+			":now":   &types.AttributeValueMemberN{Value: strconv.Itoa(now)},
+		},
+		KeyConditionExpression: aws.String("#queue = :queue AND next_attempt_at <= :now"),
+		Limit:                  aws.Int32(int32(limit)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var jobs []Job
+	if err := attributevalue.UnmarshalListOfMaps(output.Items, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (q *DynamoQueue) Claim(ctx context.Context, job *Job, now, leaseUntil int) error {
+	_, err := q.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(q.tableName),
+		Key: map[string]types.AttributeValue{
+			"jobid": &types.AttributeValueMemberS{Value: job.JobId},
+		},
+		ExpressionAttributeNames: map[string]string{
+			"#queue": "queue",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":queue":       &types.AttributeValueMemberS{Value: QUEUE_PENDING},
+			":now":         &types.AttributeValueMemberN{Value: strconv.Itoa(now)},
+			":attempts":    &types.AttributeValueMemberN{Value: strconv.Itoa(job.Attempts)},
+			":increment":   &types.AttributeValueMemberN{Value: "1"},
+			":lease_until": &types.AttributeValueMemberN{Value: strconv.Itoa(leaseUntil)},
+		},
+		// the attempts counter acts as version, a job can only be claimed once per attempt.
+		ConditionExpression: aws.String("#queue = :queue AND next_attempt_at <= :now AND attempts = :attempts"),
+		UpdateExpression:    aws.String("SET attempts = attempts + :increment, next_attempt_at = :lease_until"),
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return ErrJobClaimed
+		}
+		return err
+	}
+	job.Attempts++
+	job.NextAttemptAt = leaseUntil
+	return nil
+}
+
+func (q *DynamoQueue) Complete(ctx context.Context, job *Job) error {
+	return q.finish(ctx, job, STATUS_SENT, "")
+}
+
+func (q *DynamoQueue) Retry(ctx context.Context, job *Job, nextAttemptAt int, reason string) error {
+	_, err := q.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(q.tableName),
+		Key: map[string]types.AttributeValue{
+			"jobid": &types.AttributeValueMemberS{Value: job.JobId},
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":attempts":        &types.AttributeValueMemberN{Value: strconv.Itoa(job.Attempts)},
+			":next_attempt_at": &types.AttributeValueMemberN{Value: strconv.Itoa(nextAttemptAt)},
+			":last_error":      &types.AttributeValueMemberS{Value: reason},
+		},
+		ConditionExpression: aws.String("attempts = :attempts"),
+		UpdateExpression:    aws.String("SET next_attempt_at = :next_attempt_at, last_error = :last_error"),
+	})
+	if err != nil {
+		return err
+	}
+	job.NextAttemptAt = nextAttemptAt
+	job.LastError = reason
+	return nil
+}
+
+func (q *DynamoQueue) DeadLetter(ctx context.Context, job *Job, reason string) error {
+	return q.finish(ctx, job, STATUS_DEAD, reason)
+}
+
+// finish removes the job from the queue and sets its final status.
+// finished jobs are removed by the ttl process after the retention period.
+func (q *DynamoQueue) finish(ctx context.Context, job *Job, status, reason string) error {
+	expiresIn := time.Now().Add(time.Duration(q.retentionDays) * 24 * time.Hour).Unix()
+	_, err := q.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(q.tableName),
+		Key: map[string]types.AttributeValue{
+			"jobid": &types.AttributeValueMemberS{Value: job.JobId},
+		},
+		ExpressionAttributeNames: map[string]string{
+			"#queue":  "queue",
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":attempts":   &types.AttributeValueMemberN{Value: strconv.Itoa(job.Attempts)},
+			":status":     &types.AttributeValueMemberS{Value: status},
+			":last_error": &types.AttributeValueMemberS{Value: reason},
+			":expires_in": &types.AttributeValueMemberN{Value: strconv.Itoa(int(expiresIn))},
+		},
+		ConditionExpression: aws.String("attempts = :attempts"),
+		UpdateExpression:    aws.String("SET #status = :status, last_error = :last_error, expires_in = :expires_in REMOVE #queue"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update job status: %v", err)
+	}
+	job.Status = status
+	job.LastError = reason
+	return nil
+}
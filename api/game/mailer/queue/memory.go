@@ -0,0 +1,120 @@
+package queue
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// MemoryQueue is an in-memory outbox queue, it is used to run the mailer locally.
+type MemoryQueue struct {
+	mutex sync.Mutex
+	jobs  map[string]*Job
+}
+
+func NewMemoryQueue(jobs []Job) *MemoryQueue {
+	q := &MemoryQueue{jobs: map[string]*Job{}}
+	for _, job := range jobs {
+		job := job
+		if job.Status == "" {
+			job.Status = STATUS_QUEUED
+		}
+		q.jobs[job.JobId] = &job
+	}
+	return q
+}
+
+// Pending returns the number of jobs that are still in the queue and the earliest next attempt time.
+func (q *MemoryQueue) Pending() (int, int) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	pending, next := 0, 0
+	for _, job := range q.jobs {
+		if job.Status != STATUS_QUEUED {
+			continue
+		}
+		if pending == 0 || job.NextAttemptAt < next {
+			next = job.NextAttemptAt
+		}
+		pending++
+	}
+	return pending, next
+}
+
+// Jobs returns a snapshot of all jobs sorted by jobid.
+func (q *MemoryQueue) Jobs() []Job {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	jobs := []Job{}
+	for _, job := range q.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].JobId < jobs[j].JobId
+	})
+	return jobs
+}
+
+func (q *MemoryQueue) Due(ctx context.Context, now, limit int) ([]Job, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	jobs := []Job{}
+	for _, job := range q.jobs {
+		if job.Status == STATUS_QUEUED && job.NextAttemptAt <= now {
+			jobs = append(jobs, *job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].NextAttemptAt < jobs[j].NextAttemptAt
+	})
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, nil
+}
+
+func (q *MemoryQueue) Claim(ctx context.Context, job *Job, now, leaseUntil int) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	stored, ok := q.jobs[job.JobId]
+	if !ok || stored.Status != STATUS_QUEUED || stored.NextAttemptAt > now || stored.Attempts != job.Attempts {
+		return ErrJobClaimed
+	}
+	stored.Attempts++
+	stored.NextAttemptAt = leaseUntil
+	*job = *stored
+	return nil
+}
+
+func (q *MemoryQueue) Complete(ctx context.Context, job *Job) error {
+	return q.update(job, func(stored *Job) {
+		stored.Status = STATUS_SENT
+		stored.LastError = ""
+	})
+}
+
+func (q *MemoryQueue) Retry(ctx context.Context, job *Job, nextAttemptAt int, reason string) error {
+	return q.update(job, func(stored *Job) {
+		stored.NextAttemptAt = nextAttemptAt
+		stored.LastError = reason
+	})
+}
+
+func (q *MemoryQueue) DeadLetter(ctx context.Context, job *Job, reason string) error {
+	return q.update(job, func(stored *Job) {
+		stored.Status = STATUS_DEAD
+		stored.LastError = reason
+	})
+}
+
+func (q *MemoryQueue) update(job *Job, apply func(*Job)) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	stored, ok := q.jobs[job.JobId]
+	if !ok || stored.Attempts != job.Attempts {
+		return ErrJobClaimed
+	}
+	apply(stored)
+	*job = *stored
+	return nil
+}
@@ -0,0 +1,57 @@
+// contains the mail outbox queue.
+// jobs are written to the outbox by the api handlers (in the same transaction as the game)
+// and are consumed by the mailer, which retries failed jobs with exponential backoff.
+package queue
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	STATUS_QUEUED = "queued"
+	STATUS_SENT   = "sent"
+	STATUS_DEAD   = "dead"
+)
+
+const (
+	// jobs without kind are confirmation mails.
+	KIND_CONFIRM = ""
+	KIND_EXPIRE  = "expire"
+	KIND_REMIND  = "remind"
+)
+
+// ErrJobClaimed indicates that the job was claimed by another worker (or is no longer due).
+var ErrJobClaimed = errors.New("job was already claimed")
+
+// Job is a mail job of the outbox. jobs of imports refer to multiple games (gameids),
+// their gameid is the last game of the import.
+type Job struct {
+	JobId         string   `dynamodbav:"jobid" json:"jobid"`
+	Kind          string   `dynamodbav:"kind" json:"kind"`
+	GameId        string   `dynamodbav:"gameid" json:"gameid"`
+	GameIds       []string `dynamodbav:"gameids" json:"gameids"`
+	Participant   string   `dynamodbav:"participant" json:"participant"`
+	Email         string   `dynamodbav:"email" json:"email"`
+	Template      string   `dynamodbav:"template" json:"template"`
+	TemplateData  string   `dynamodbav:"template_data" json:"template_data"`
+	Attempts      int      `dynamodbav:"attempts" json:"attempts"`
+	NextAttemptAt int      `dynamodbav:"next_attempt_at" json:"next_attempt_at"`
+	Status        string   `dynamodbav:"status" json:"status"`
+	LastError     string   `dynamodbav:"last_error" json:"last_error"`
+}
+
+// Queue abstracts the storage of the outbox, this allows running the mailer against an in-memory queue locally.
+type Queue interface {
+	// Due returns up to limit jobs that are due at the specified unix time.
+	Due(ctx context.Context, now, limit int) ([]Job, error)
+	// Claim leases the job until the specified unix time and increments its attempts.
+	// if the job was claimed concurrently ErrJobClaimed is returned.
+	Claim(ctx context.Context, job *Job, now, leaseUntil int) error
+	// Complete marks the job as sent and removes it from the queue.
+	Complete(ctx context.Context, job *Job) error
+	// Retry schedules the job for another attempt at the specified unix time.
+	Retry(ctx context.Context, job *Job, nextAttemptAt int, reason string) error
+	// DeadLetter marks the job as dead and removes it from the queue, it is kept for inspection.
+	DeadLetter(ctx context.Context, job *Job, reason string) error
+}
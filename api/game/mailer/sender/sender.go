@@ -0,0 +1,72 @@
+// contains the mail senders used by the mailer.
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+	"github.com/megakuul/leaderboard/api/game/mailer/queue"
+)
+
+// ErrPermanent marks errors that will not resolve by retrying (e.g. rejected recipients).
+var ErrPermanent = errors.New("permanent delivery failure")
+
+type Sender interface {
+	Send(ctx context.Context, job *queue.Job) error
+}
+
+// SESSender delivers the jobs as templated mails via aws ses.
+type SESSender struct {
+	client     *sesv2.Client
+	senderMail string
+}
+
+func NewSESSender(sesClient *sesv2.Client, senderMail string) *SESSender {
+	return &SESSender{
+		client:     sesClient,
+		senderMail: senderMail,
+	}
+}
+
+func (s *SESSender) Send(ctx context.Context, job *queue.Job) error {
+	_, err := s.client.SendEmail(ctx, &sesv2.SendEmailInput{
+		FromEmailAddress: aws.String(s.senderMail),
+		Destination: &types.Destination{
+			ToAddresses: []string{job.Email},
+		},
+		Content: &types.EmailContent{
+			Template: &types.Template{
+				TemplateName: aws.String(job.Template),
+				TemplateData: aws.String(job.TemplateData),
+			},
+		},
+	})
+	if err != nil {
+		var rejectedErr *types.MessageRejected
+		var badRequestErr *types.BadRequestException
+		if errors.As(err, &rejectedErr) || errors.As(err, &badRequestErr) {
+			return fmt.Errorf("%w: %v", ErrPermanent, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// LogSender prints the jobs instead of delivering them, it is used to run the mailer locally.
+// the first FailAttempts attempts of every job fail, which allows testing the retry behavior.
+type LogSender struct {
+	FailAttempts int
+}
+
+func (s *LogSender) Send(ctx context.Context, job *queue.Job) error {
+	if job.Attempts <= s.FailAttempts {
+		return fmt.Errorf("simulated delivery failure (attempt %d)", job.Attempts)
+	}
+	log.Printf("MAIL %s: to=%s template=%s data=%s\n", job.JobId, job.Email, job.Template, job.TemplateData)
+	return nil
+}
@@ -0,0 +1,72 @@
+// contains wrappers for database update functions.
+// main purpose is to abstract some boilerplate code
+// away from the handler.
+package update
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// UpdateMailStatus records the delivery status of the confirmation mail on the participant.
+// if the game no longer exists (e.g. it expired), the update is skipped.
+func UpdateMailStatus(dynamoClient *dynamodb.Client, ctx context.Context, tableName, gameid, participant, status string) error {
+	_, err := dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"gameid": &types.AttributeValueMemberS{Value: gameid},
+		},
+		ExpressionAttributeNames: map[string]string{
+			"#participants": "participants",
+			"#participant":  participant,
+			"#mail_status":  "mail_status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":mail_status": &types.AttributeValueMemberS{Value: status},
+		},
+		ConditionExpression: aws.String("attribute_exists(#participants.#participant)"),
+		UpdateExpression:    aws.String("SET #participants.#participant.#mail_status = :mail_status"),
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// SetRemindedAt records that the reminder mail was sent to the participant.
+// if the participant no longer exists (e.g. the game expired or was edited), the update is skipped.
+func SetRemindedAt(dynamoClient *dynamodb.Client, ctx context.Context, tableName, gameid, participant string, remindedAt int) error {
+	_, err := dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"gameid": &types.AttributeValueMemberS{Value: gameid},
+		},
+		ExpressionAttributeNames: map[string]string{
+			"#participants": "participants",
+			"#participant":  participant,
+			"#reminded_at":  "reminded_at",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":reminded_at": &types.AttributeValueMemberN{Value: strconv.Itoa(remindedAt)},
+		},
+		ConditionExpression: aws.String("attribute_exists(#participants.#participant)"),
+		UpdateExpression:    aws.String("SET #participants.#participant.#reminded_at = :reminded_at"),
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/megakuul/leaderboard/api/game/remind/outbox"
+	"github.com/megakuul/leaderboard/api/game/remind/query"
+	"github.com/megakuul/leaderboard/api/game/remind/update"
+)
+
+func RemindHandler(dynamoClient *dynamodb.Client) func(context.Context, events.EventBridgeEvent) error {
+	return func(ctx context.Context, event events.EventBridgeEvent) error {
+		if err := runRemindHandler(dynamoClient, ctx); err != nil {
+			log.Printf("ERROR: %v\n", err)
+			return err
+		}
+		return nil
+	}
+}
+
+func runRemindHandler(dynamoClient *dynamodb.Client, ctx context.Context) error {
+	now := time.Now()
+	games, err := query.FetchExpiringGames(dynamoClient, ctx, GAMETABLE, int(now.Unix()),
+		int(now.Add(time.Duration(REMIND_HOURS_BEFORE_EXPIRY)*time.Hour).Unix()))
+	if err != nil {
+		return fmt.Errorf("failed to fetch expiring games: %v", err)
+	}
+
+	users := map[string]*query.UserOutput{}
+	enqueued := 0
+	for _, game := range games {
+		if game.Readonly {
+			continue
+		}
+		for key, part := range game.Participants {
+			if part.Confirmed || part.RemindedAt != 0 {
+				continue
+			}
+
+			user, ok := users[part.Subject]
+			if !ok {
+				user, err = query.FetchBySubject(dynamoClient, ctx, USERTABLE, part.Subject)
+				if err != nil {
+					log.Printf("WARNING: failed to lookup %s: %v\n", part.Subject, err)
+					continue
+				}
+				users[part.Subject] = user
+			}
+			// disabled users opted out of mails.
+			if user.Disabled {
+				continue
+			}
+
+			mailJob, err := outbox.BuildRemindJob(MAILTEMPLATE, &outbox.EmailRemindRequest{
+				GameId:      game.GameId,
+				Participant: key,
+				Name:        game.Name,
+				PlayedAt:    formatPlayedAt(game.PlayedAt),
+				ExpiresAt:   time.Unix(int64(game.ExpiresIn), 0).UTC().Format(time.RFC1123),
+				Username:    part.Username,
+				Email:       user.Email,
+				Secret:      part.ConfirmSecret,
+				Placement:   part.Placement,
+				Points:      part.Points,
+				EloUpdate:   part.EloUpdate,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to build reminder mail: %v", err)
+			}
+
+			err = update.EnqueueReminder(dynamoClient, ctx, GAMETABLE, OUTBOXTABLE, game.GameId, key, part.ConfirmSecret, &mailJob)
+			if errors.Is(err, update.ErrNotRemindable) {
+				continue
+			} else if err != nil {
+				log.Printf("WARNING: failed to enqueue reminder of %s on game %s: %v\n", part.Username, game.GameId, err)
+				continue
+			}
+			enqueued++
+		}
+	}
+
+	log.Printf("enqueued %d reminders\n", enqueued)
+	return nil
+}
+
+// formatPlayedAt formats the stored RFC3339 timestamp for the mail (in the timezone the game was played in).
+func formatPlayedAt(playedAt string) string {
+	playedAtTime, err := time.Parse(time.RFC3339, playedAt)
+	if err != nil {
+		return playedAt
+	}
+	return playedAtTime.Format(time.RFC1123)
+}
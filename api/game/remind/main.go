@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+var (
+	REGION                     = os.Getenv("AWS_REGION")
+	USERTABLE                  = os.Getenv("USERTABLE")
+	GAMETABLE                  = os.Getenv("GAMETABLE")
+	MAILTEMPLATE               = os.Getenv("MAILTEMPLATE")
+	OUTBOXTABLE                = os.Getenv("OUTBOXTABLE")
+	REMIND_HOURS_BEFORE_EXPIRY = 6 // default 6
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatalf("ERROR INITIALIZATION: %v\n", err)
+	}
+}
+
+func run() error {
+	awsConfig, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(REGION))
+	if err != nil {
+		return fmt.Errorf("failed to load aws config: %v", err)
+	}
+	dynamoClient := dynamodb.NewFromConfig(awsConfig)
+
+	if remindHoursBeforeExpiry, err := strconv.Atoi(os.Getenv("REMIND_HOURS_BEFORE_EXPIRY")); err == nil {
+		REMIND_HOURS_BEFORE_EXPIRY = remindHoursBeforeExpiry
+	}
+
+	lambda.Start(RemindHandler(dynamoClient))
+	return nil
+}
@@ -0,0 +1,69 @@
+// contains helpers to build the reminder mail jobs of the outbox.
+// the jobs are delivered by the mailer, which records the reminder on the participant.
+package outbox
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/megakuul/leaderboard/api/game/remind/update"
+)
+
+type EmailRemindRequest struct {
+	GameId      string
+	Participant string
+	Name        string
+	PlayedAt    string
+	ExpiresAt   string
+	Username    string
+	Email       string
+	Secret      string
+	Placement   int
+	Points      int
+	EloUpdate   int
+}
+
+type emailTemplateInput struct {
+	GameId    string `json:"gameid"`
+	Name      string `json:"name"`
+	PlayedAt  string `json:"played_at"`
+	ExpiresAt string `json:"expires_at"`
+	Username  string `json:"username"`
+	Secret    string `json:"secret"`
+	Placement int    `json:"placement"`
+	Points    int    `json:"points"`
+	EloUpdate int    `json:"elo_update"`
+}
+
+// BuildRemindJob creates the reminder mail job of a participant.
+// the jobid is derived from the confirmation secret, so that every version of the game (secrets are rotated on edits)
+// is reminded at most once.
+func BuildRemindJob(mailTemplate string, request *EmailRemindRequest) (update.MailJobInput, error) {
+	templateInput := emailTemplateInput{
+		GameId:    request.GameId,
+		Name:      request.Name,
+		PlayedAt:  request.PlayedAt,
+		ExpiresAt: request.ExpiresAt,
+		Username:  request.Username,
+		Secret:    request.Secret,
+		Placement: request.Placement,
+		Points:    request.Points,
+		EloUpdate: request.EloUpdate,
+	}
+	templateInputSerialized, err := json.Marshal(&templateInput)
+	if err != nil {
+		return update.MailJobInput{}, fmt.Errorf("failed to serialize mail input")
+	}
+	secretHash := sha256.Sum256([]byte(request.Secret))
+	return update.MailJobInput{
+		JobId:        fmt.Sprintf("%s#%s#%s#%s", request.GameId, request.Participant, update.MAIL_KIND_REMIND, hex.EncodeToString(secretHash[:8])),
+		Kind:         update.MAIL_KIND_REMIND,
+		GameId:       request.GameId,
+		Participant:  request.Participant,
+		Email:        request.Email,
+		Template:     mailTemplate,
+		TemplateData: string(templateInputSerialized),
+	}, nil
+}
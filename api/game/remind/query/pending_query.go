@@ -0,0 +1,48 @@
+package query
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// FetchExpiringGames fetches all pending games that expire in the specified time range (unix time).
+// the pending_gsi only contains pending games, finalized games drop out of the index.
+func FetchExpiringGames(dynamoClient *dynamodb.Client, ctx context.Context, tableName string, from, to int) ([]GameOutput, error) {
+	games := []GameOutput{}
+	var lastEvaluatedKey map[string]types.AttributeValue
+	for {
+		output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
+			TableName: aws.String(tableName),
+			IndexName: aws.String("pending_gsi"),
+			ExpressionAttributeNames: map[string]string{
+				"#pending":    "pending",
+				"#expires_in": "expires_in",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pending": &types.AttributeValueMemberS{Value: "true"},
+				":from":    &types.AttributeValueMemberN{Value: strconv.Itoa(from)},
+				":to":      &types.AttributeValueMemberN{Value: strconv.Itoa(to)},
+			},
+			KeyConditionExpression: aws.String("#pending = :pending AND #expires_in BETWEEN :from AND :to"),
+			ExclusiveStartKey:      lastEvaluatedKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		var page []GameOutput
+		if err := attributevalue.UnmarshalListOfMaps(output.Items, &page); err != nil {
+			return nil, err
+		}
+		games = append(games, page...)
+
+		lastEvaluatedKey = output.LastEvaluatedKey
+		if len(lastEvaluatedKey) < 1 {
+			return games, nil
+		}
+	}
+}
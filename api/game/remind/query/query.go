@@ -0,0 +1,31 @@
+// contains wrappers for database query functions.
+// main purpose is to abstract some boilerplate code
+// away from the handler.
+package query
+
+type UserOutput struct {
+	Subject  string `dynamodbav:"subject"`
+	Disabled bool   `dynamodbav:"disabled"`
+	Username string `dynamodbav:"username"`
+	Email    string `dynamodbav:"email"`
+}
+
+type ParticipantOutput struct {
+	Subject       string `dynamodbav:"subject"`
+	Username      string `dynamodbav:"username"`
+	Placement     int    `dynamodbav:"placement"`
+	Points        int    `dynamodbav:"points"`
+	EloUpdate     int    `dynamodbav:"elo_update"`
+	Confirmed     bool   `dynamodbav:"confirmed"`
+	ConfirmSecret string `dynamodbav:"confirm_secret"`
+	RemindedAt    int    `dynamodbav:"reminded_at"`
+}
+
+type GameOutput struct {
+	GameId       string                       `dynamodbav:"gameid"`
+	Name         string                       `dynamodbav:"name"`
+	PlayedAt     string                       `dynamodbav:"played_at"`
+	Readonly     bool                         `dynamodbav:"readonly"`
+	ExpiresIn    int                          `dynamodbav:"expires_in"`
+	Participants map[string]ParticipantOutput `dynamodbav:"participants"`
+}
@@ -0,0 +1,104 @@
+// contains wrappers for database update functions.
+// main purpose is to abstract some boilerplate code
+// away from the handler.
+package update
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// reminder mails set reminded_at on the participant once they are sent.
+	MAIL_KIND_REMIND = "remind"
+)
+
+// ErrNotRemindable indicates that the participant was already reminded, confirmed in the meantime
+// or that the game was finalized or edited.
+var ErrNotRemindable = errors.New("participant is not remindable")
+
+// MailJobInput is a mail job of the outbox, it is delivered by the mailer.
+type MailJobInput struct {
+	JobId         string `dynamodbav:"jobid"`
+	Kind          string `dynamodbav:"kind"`
+	GameId        string `dynamodbav:"gameid"`
+	Participant   string `dynamodbav:"participant"`
+	Email         string `dynamodbav:"email"`
+	Template      string `dynamodbav:"template"`
+	TemplateData  string `dynamodbav:"template_data"`
+	Attempts      int    `dynamodbav:"attempts"`
+	NextAttemptAt int    `dynamodbav:"next_attempt_at"`
+	Status        string `dynamodbav:"status"`
+	Queue         string `dynamodbav:"queue"`
+}
+
+// EnqueueReminder writes the reminder mail job of the participant to the outbox.
+// The game is checked in the same transaction and the job is only written once, concurrent or repeated runs
+// therefore never enqueue a second reminder. reminded_at is set by the mailer after the mail was sent.
+func EnqueueReminder(dynamoClient *dynamodb.Client, ctx context.Context, tableName, outboxTableName, gameid, participant, confirmSecret string, mailJob *MailJobInput) error {
+	mailJob.Attempts = 0
+	mailJob.NextAttemptAt = int(time.Now().Unix())
+	mailJob.Status = "queued"
+	mailJob.Queue = "pending"
+	mailJobSerialized, err := attributevalue.MarshalMap(mailJob)
+	if err != nil {
+		return fmt.Errorf("failed to serialize mail job")
+	}
+
+	_, err = dynamoClient.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				ConditionCheck: &types.ConditionCheck{
+					TableName: aws.String(tableName),
+					Key: map[string]types.AttributeValue{
+						"gameid": &types.AttributeValueMemberS{Value: gameid},
+					},
+					ExpressionAttributeNames: map[string]string{
+						"#readonly":       "readonly",
+						"#participants":   "participants",
+						"#participant":    participant,
+						"#confirmed":      "confirmed",
+						"#confirm_secret": "confirm_secret",
+						"#reminded_at":    "reminded_at",
+					},
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":not_readonly":   &types.AttributeValueMemberBOOL{Value: false},
+						":not_confirmed":  &types.AttributeValueMemberBOOL{Value: false},
+						":confirm_secret": &types.AttributeValueMemberS{Value: confirmSecret},
+					},
+					// the secret ensures that the game was not edited since it was fetched (secrets are rotated on edits).
+					ConditionExpression: aws.String("#readonly = :not_readonly AND " +
+						"#participants.#participant.#confirmed = :not_confirmed AND " +
+						"#participants.#participant.#confirm_secret = :confirm_secret AND " +
+						"attribute_not_exists(#participants.#participant.#reminded_at)"),
+				},
+			},
+			{
+				Put: &types.Put{
+					TableName:           aws.String(outboxTableName),
+					Item:                mailJobSerialized,
+					ConditionExpression: aws.String("attribute_not_exists(jobid)"),
+				},
+			},
+		},
+	})
+	if err != nil {
+		var cancelErr *types.TransactionCanceledException
+		if errors.As(err, &cancelErr) {
+			for _, reason := range cancelErr.CancellationReasons {
+				if aws.ToString(reason.Code) == "ConditionalCheckFailed" {
+					return ErrNotRemindable
+				}
+			}
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/megakuul/leaderboard/api/game/revert/auth"
+	"github.com/megakuul/leaderboard/api/game/revert/query"
+	"github.com/megakuul/leaderboard/api/game/revert/update"
+)
+
+const (
+	MAX_REASON_LENGTH = 200
+)
+
+type RevertRequest struct {
+	GameId string `json:"gameid"`
+	Reason string `json:"reason"`
+}
+
+type RevertResponse struct {
+	Message string `json:"message"`
+	GameId  string `json:"gameid"`
+}
+
+func RevertHandler(dynamoClient *dynamodb.Client) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		response, code, err := runRevertHandler(dynamoClient, &request, ctx)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: code,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       err.Error(),
+			}, nil
+		}
+		serializedResponse, err := json.Marshal(&response)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: http.StatusInternalServerError,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       "failed to serialize response",
+			}, nil
+		}
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode: code,
+			Headers:    map[string]string{"Content-Type": "application/json"},
+			Body:       string(serializedResponse),
+		}, nil
+	}
+}
+
+func runRevertHandler(dynamoClient *dynamodb.Client, request *events.APIGatewayV2HTTPRequest, ctx context.Context) (*RevertResponse, int, error) {
+	claims := request.RequestContext.Authorizer.JWT.Claims
+	sub := claims["sub"]
+	if sub == "" {
+		return nil, http.StatusUnprocessableEntity, fmt.Errorf("invalid sub claim in the ID token")
+	}
+
+	if !auth.IsGroupMember(claims, ADMINGROUP) {
+		return nil, http.StatusForbidden, fmt.Errorf("only members of the '%s' group can revert games", ADMINGROUP)
+	}
+
+	var req RevertRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to deserialize request: invalid body")
+	}
+
+	if req.GameId == "" {
+		return nil, http.StatusBadRequest, fmt.Errorf("missing gameid")
+	}
+
+	if req.Reason == "" {
+		return nil, http.StatusBadRequest, fmt.Errorf("missing revert reason")
+	}
+
+	if len(req.Reason) > MAX_REASON_LENGTH {
+		return nil, http.StatusBadRequest, fmt.Errorf("maximum reason length is %d", MAX_REASON_LENGTH)
+	}
+
+	game, err := query.FetchById(dynamoClient, ctx, GAMETABLE, req.GameId)
+	if err != nil {
+		return nil, http.StatusNotFound, fmt.Errorf("failed to revert: %v", err)
+	}
+
+	if !game.Readonly {
+		return nil, http.StatusBadRequest, fmt.Errorf("only finalized games can be reverted")
+	}
+
+	if game.Reverted {
+		return nil, http.StatusBadRequest, fmt.Errorf("the game was already reverted")
+	}
+
+	revertParticipants := []update.ParticipantInput{}
+	for _, part := range game.Participants {
+		// deleted users have no user item anymore, their elo can not be reverted.
+		if part.Deleted {
+			continue
+		}
+		revertParticipants = append(revertParticipants, update.ParticipantInput{
+			Subject:   part.Subject,
+			EloUpdate: part.EloUpdate,
+		})
+	}
+
+	err = update.RevertGame(dynamoClient, ctx, USERTABLE, GAMETABLE, game.GameId, &update.RevertInput{
+		Reason:        req.Reason,
+		ActorSubject:  sub,
+		ActorUsername: claims["preferred_username"],
+		Participants:  revertParticipants,
+	})
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to revert game: %v", err)
+	}
+
+	return &RevertResponse{
+		Message: "successfully reverted game",
+		GameId:  game.GameId,
+	}, http.StatusOK, nil
+}
@@ -0,0 +1,18 @@
+// contains wrappers for database query functions.
+// main purpose is to abstract some boilerplate code
+// away from the handler.
+package query
+
+type ParticipantOutput struct {
+	Subject   string `dynamodbav:"subject"`
+	Username  string `dynamodbav:"username"`
+	EloUpdate int    `dynamodbav:"elo_update"`
+	Deleted   bool   `dynamodbav:"deleted"`
+}
+
+type GameOutput struct {
+	GameId       string                       `dynamodbav:"gameid"`
+	Readonly     bool                         `dynamodbav:"readonly"`
+	Reverted     bool                         `dynamodbav:"reverted"`
+	Participants map[string]ParticipantOutput `dynamodbav:"participants"`
+}
@@ -0,0 +1,87 @@
+// contains wrappers for database update functions.
+// main purpose is to abstract some boilerplate code
+// away from the handler.
+package update
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type ParticipantInput struct {
+	Subject   string
+	EloUpdate int
+}
+
+type RevertInput struct {
+	Reason        string
+	ActorSubject  string
+	ActorUsername string
+	Participants  []ParticipantInput
+}
+
+// RevertGame applies the negated elo update of every participant and marks the game as reverted.
+// all updates are performed in one transaction, the game is kept for audit purposes.
+func RevertGame(dynamoClient *dynamodb.Client, ctx context.Context, userTableName, gameTableName, gameid string, revertInput *RevertInput) error {
+	transactItems := []types.TransactWriteItem{}
+	for _, part := range revertInput.Participants {
+		transactItems = append(transactItems, types.TransactWriteItem{
+			Update: &types.Update{
+				TableName: aws.String(userTableName),
+				Key: map[string]types.AttributeValue{
+					"subject": &types.AttributeValueMemberS{Value: part.Subject},
+				},
+				ExpressionAttributeNames: map[string]string{
+					"#elo": "elo",
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":elo_update": &types.AttributeValueMemberN{Value: strconv.Itoa(-part.EloUpdate)},
+				},
+				ConditionExpression: aws.String("attribute_exists(subject)"), // prevent it to upsert if not existent
+				UpdateExpression:    aws.String("ADD #elo :elo_update"),
+			},
+		})
+	}
+
+	transactItems = append(transactItems, types.TransactWriteItem{
+		Update: &types.Update{
+			TableName: aws.String(gameTableName),
+			Key: map[string]types.AttributeValue{
+				"gameid": &types.AttributeValueMemberS{Value: gameid},
+			},
+			ExpressionAttributeNames: map[string]string{
+				"#readonly":            "readonly",
+				"#reverted":            "reverted",
+				"#revert_reason":       "revert_reason",
+				"#reverted_by":         "reverted_by",
+				"#reverted_by_subject": "reverted_by_subject",
+				"#reverted_at":         "reverted_at",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":readonly":            &types.AttributeValueMemberBOOL{Value: true},
+				":reverted":            &types.AttributeValueMemberBOOL{Value: true},
+				":revert_reason":       &types.AttributeValueMemberS{Value: revertInput.Reason},
+				":reverted_by":         &types.AttributeValueMemberS{Value: revertInput.ActorUsername},
+				":reverted_by_subject": &types.AttributeValueMemberS{Value: revertInput.ActorSubject},
+				":reverted_at":         &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Unix(), 10)},
+			},
+			// only finalized games that were not reverted yet can be reverted
+			ConditionExpression: aws.String("#readonly = :readonly AND (attribute_not_exists(#reverted) OR #reverted <> :reverted)"),
+			UpdateExpression: aws.String(
+				"SET #reverted = :reverted, #revert_reason = :revert_reason, #reverted_by = :reverted_by, #reverted_by_subject = :reverted_by_subject, #reverted_at = :reverted_at"),
+		},
+	})
+
+	_, err := dynamoClient.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/megakuul/leaderboard/api/user/avatar/presign"
+)
+
+// content types accepted for avatar uploads, the processor validates the actual image content.
+var ALLOWED_CONTENT_TYPES = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+type AvatarRequest struct {
+	ContentType   string `json:"content_type"`
+	ContentLength int64  `json:"content_length"`
+}
+
+type AvatarResponse struct {
+	Message string               `json:"message"`
+	Upload  presign.UploadOutput `json:"upload"`
+}
+
+func AvatarHandler(presignClient *s3.PresignClient) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		response, code, err := runAvatarHandler(presignClient, &request, ctx)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: code,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       err.Error(),
+			}, nil
+		}
+		serializedResponse, err := json.Marshal(&response)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: http.StatusInternalServerError,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       "failed to serialize response",
+			}, nil
+		}
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode: code,
+			Headers:    map[string]string{"Content-Type": "application/json"},
+			Body:       string(serializedResponse),
+		}, nil
+	}
+}
+
+func runAvatarHandler(presignClient *s3.PresignClient, request *events.APIGatewayV2HTTPRequest, ctx context.Context) (*AvatarResponse, int, error) {
+	sub := request.RequestContext.Authorizer.JWT.Claims["sub"]
+	if sub == "" {
+		return nil, http.StatusUnprocessableEntity, fmt.Errorf("invalid sub claim in the ID token")
+	}
+
+	var req AvatarRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to deserialize request: invalid body")
+	}
+
+	if !ALLOWED_CONTENT_TYPES[req.ContentType] {
+		return nil, http.StatusBadRequest, fmt.Errorf("unsupported content type: expected png, jpeg, gif or webp")
+	}
+	if req.ContentLength < 1 || req.ContentLength > int64(MAX_UPLOAD_BYTES) {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid content length: maximum avatar size is %d bytes", MAX_UPLOAD_BYTES)
+	}
+
+	upload, err := presign.PresignAvatarUpload(presignClient, ctx, AVATARBUCKET, sub, req.ContentType, req.ContentLength,
+		time.Duration(UPLOAD_EXPIRY_SECONDS)*time.Second)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to presign upload: %v", err)
+	}
+
+	return &AvatarResponse{
+		Message: "successfully issued avatar upload, the avatar is set after it was processed",
+		Upload:  *upload,
+	}, http.StatusOK, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+var (
+	REGION                = os.Getenv("AWS_REGION")
+	AVATARBUCKET          = os.Getenv("AVATARBUCKET")
+	MAX_UPLOAD_BYTES      = 2097152 // default 2097152
+	UPLOAD_EXPIRY_SECONDS = 300     // default 300
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatalf("ERROR INITIALIZATION: %v\n", err)
+	}
+}
+
+func run() error {
+	awsConfig, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(REGION))
+	if err != nil {
+		return fmt.Errorf("failed to load aws config: %v", err)
+	}
+	presignClient := s3.NewPresignClient(s3.NewFromConfig(awsConfig))
+
+	if maxUploadBytes, err := strconv.Atoi(os.Getenv("MAX_UPLOAD_BYTES")); err == nil {
+		MAX_UPLOAD_BYTES = maxUploadBytes
+	}
+	if uploadExpirySeconds, err := strconv.Atoi(os.Getenv("UPLOAD_EXPIRY_SECONDS")); err == nil {
+		UPLOAD_EXPIRY_SECONDS = uploadExpirySeconds
+	}
+
+	lambda.Start(AvatarHandler(presignClient))
+	return nil
+}
@@ -0,0 +1,73 @@
+// contains wrappers for the presigned avatar uploads.
+// main purpose is to abstract some boilerplate code
+// away from the handler.
+package presign
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const (
+	// uploads are stored below this prefix until they are processed, the prefix is not served by the cdn.
+	UPLOAD_PREFIX = "avatars/uploads"
+)
+
+type UploadOutput struct {
+	Url       string            `json:"upload_url"`
+	Method    string            `json:"upload_method"`
+	Headers   map[string]string `json:"upload_headers"`
+	ExpiresAt int               `json:"expires_at"`
+}
+
+// PresignAvatarUpload issues a presigned put of an avatar image of the subject.
+// content type and length are signed, the upload is rejected by s3 if the client sends other values.
+// the upload key contains the subject, so that the processor can assign the avatar to the user.
+func PresignAvatarUpload(presignClient *s3.PresignClient, ctx context.Context, bucketName, subject, contentType string, contentLength int64, expiry time.Duration) (*UploadOutput, error) {
+	avatarId, err := generateAvatarId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate avatar id: %v", err)
+	}
+
+	request, err := presignClient.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(bucketName),
+		Key:           aws.String(fmt.Sprintf("%s/%s/%s", UPLOAD_PREFIX, subject, avatarId)),
+		ContentType:   aws.String(contentType),
+		ContentLength: aws.Int64(contentLength),
+	}, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{}
+	for key, values := range request.SignedHeader {
+		// the host header is set by the client itself.
+		if http.CanonicalHeaderKey(key) == "Host" || len(values) < 1 {
+			continue
+		}
+		headers[key] = values[0]
+	}
+
+	return &UploadOutput{
+		Url:       request.URL,
+		Method:    request.Method,
+		Headers:   headers,
+		ExpiresAt: int(time.Now().Add(expiry).Unix()),
+	}, nil
+}
+
+// generateAvatarId generates the random id of an avatar, it is used in the public url of the avatar.
+func generateAvatarId() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
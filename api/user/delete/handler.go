@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/megakuul/leaderboard/api/user/delete/query"
+	"github.com/megakuul/leaderboard/api/user/delete/remove"
+)
+
+type DeleteResponse struct {
+	Message         string `json:"message"`
+	AnonymizedGames int    `json:"anonymized_games"`
+}
+
+func DeleteHandler(dynamoClient *dynamodb.Client, cognitoClient *cognitoidentityprovider.Client, s3Client *s3.Client) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		response, code, err := runDeleteHandler(dynamoClient, cognitoClient, s3Client, &request, ctx)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: code,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       err.Error(),
+			}, nil
+		}
+		serializedResponse, err := json.Marshal(&response)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: http.StatusInternalServerError,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       "failed to serialize response",
+			}, nil
+		}
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode: code,
+			Headers:    map[string]string{"Content-Type": "application/json"},
+			Body:       string(serializedResponse),
+		}, nil
+	}
+}
+
+func runDeleteHandler(dynamoClient *dynamodb.Client, cognitoClient *cognitoidentityprovider.Client, s3Client *s3.Client, request *events.APIGatewayV2HTTPRequest, ctx context.Context) (*DeleteResponse, int, error) {
+	sub := request.RequestContext.Authorizer.JWT.Claims["sub"]
+	if sub == "" {
+		return nil, http.StatusUnprocessableEntity, fmt.Errorf("invalid sub claim in the ID token")
+	}
+
+	user, err := query.FetchBySubject(dynamoClient, ctx, USERTABLE, sub)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch user: %v", err)
+	}
+	// the deletion can not be undone, therefore the current username must be confirmed explicitly.
+	if user != nil && request.QueryStringParameters["confirm"] != user.Username {
+		return nil, http.StatusBadRequest, fmt.Errorf("deletion must be confirmed with the current username (confirm param)")
+	}
+
+	participations, err := query.FetchParticipations(dynamoClient, ctx, PARTICIPATIONTABLE, sub)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch participations: %v", err)
+	}
+
+	// mail jobs are deleted before the participations, legacy jobs can only be looked up via the participations.
+	mailJobs, err := query.FetchMailJobs(dynamoClient, ctx, MAILOUTBOXTABLE, sub, participations)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch mail jobs: %v", err)
+	}
+	mailJobIds := []string{}
+	for _, mailJob := range mailJobs {
+		mailJobIds = append(mailJobIds, mailJob.JobId)
+	}
+	if err := remove.DeleteMailJobs(dynamoClient, ctx, MAILOUTBOXTABLE, mailJobIds); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to delete mail jobs: %v", err)
+	}
+
+	// the participation is deleted after the game was anonymized, so that a failed deletion can be retried.
+	for _, participation := range participations {
+		err := remove.AnonymizeParticipant(dynamoClient, ctx, GAMETABLE, participation.GameId, sub, participation.Username)
+		if errors.Is(err, remove.ErrConcurrentUpdate) {
+			return nil, http.StatusConflict, fmt.Errorf("failed to anonymize game %s: %v", participation.GameId, err)
+		} else if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to anonymize game %s: %v", participation.GameId, err)
+		}
+		err = remove.DeleteParticipation(dynamoClient, ctx, PARTICIPATIONTABLE, sub, participation.SortKey)
+		if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to delete participation: %v", err)
+		}
+	}
+
+	// the audit reference is deleted after the audit record was anonymized, so that a failed deletion can be retried.
+	auditSubjects, err := query.FetchAuditSubjects(dynamoClient, ctx, AUDITTABLE, sub)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch audit records: %v", err)
+	}
+	for _, auditSubject := range auditSubjects {
+		err := remove.AnonymizeAudit(dynamoClient, ctx, AUDITTABLE, auditSubject.GameId, auditSubject.Event, auditSubject.Ref, sub)
+		if errors.Is(err, remove.ErrConcurrentUpdate) {
+			return nil, http.StatusConflict, fmt.Errorf("failed to anonymize audit record of game %s: %v", auditSubject.GameId, err)
+		} else if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to anonymize audit record of game %s: %v", auditSubject.GameId, err)
+		}
+	}
+
+	usernameHistory, err := query.FetchUsernameHistory(dynamoClient, ctx, USERNAMEHISTORYTABLE, sub)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch username history: %v", err)
+	}
+	for _, history := range usernameHistory {
+		if err := remove.DeleteUsername(dynamoClient, ctx, USERNAMEHISTORYTABLE, history.Username, sub); err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to delete username history: %v", err)
+		}
+	}
+
+	friendSubjects, err := query.FetchFriendSubjects(dynamoClient, ctx, FRIENDTABLE, sub)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch friends: %v", err)
+	}
+	for _, friendSubject := range friendSubjects {
+		if err := remove.DeleteFriendship(dynamoClient, ctx, FRIENDTABLE, sub, friendSubject); err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to delete friendship: %v", err)
+		}
+	}
+
+	if user != nil {
+		if err := remove.DeleteAvatar(s3Client, ctx, AVATARBUCKET, user.AvatarId); err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to delete avatar: %v", err)
+		}
+		// reservations created before they were normalized are keyed by the raw username.
+		for _, reservationKey := range []string{remove.NormalizeUsername(user.Username), user.Username} {
+			if err := remove.DeleteUsername(dynamoClient, ctx, USERNAMERESERVATIONTABLE, reservationKey, sub); err != nil {
+				return nil, http.StatusInternalServerError, fmt.Errorf("failed to delete username reservation: %v", err)
+			}
+		}
+		if err := remove.DeleteUser(dynamoClient, ctx, USERTABLE, sub); err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to delete user: %v", err)
+		}
+	}
+
+	// the account is deleted last, the token stays valid until it expires, so that a failed deletion can be retried.
+	cognitoUsername := request.RequestContext.Authorizer.JWT.Claims["cognito:username"]
+	if cognitoUsername == "" {
+		cognitoUsername = sub
+	}
+	if err := remove.DeleteCognitoUser(cognitoClient, ctx, USERPOOLID, cognitoUsername); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to delete account: %v", err)
+	}
+
+	return &DeleteResponse{
+		Message:         "successfully deleted user",
+		AnonymizedGames: len(participations),
+	}, http.StatusOK, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+var (
+	REGION                   = os.Getenv("AWS_REGION")
+	USERTABLE                = os.Getenv("USERTABLE")
+	USERNAMEHISTORYTABLE     = os.Getenv("USERNAMEHISTORYTABLE")
+	USERNAMERESERVATIONTABLE = os.Getenv("USERNAMERESERVATIONTABLE")
+	GAMETABLE                = os.Getenv("GAMETABLE")
+	PARTICIPATIONTABLE       = os.Getenv("PARTICIPATIONTABLE")
+	MAILOUTBOXTABLE          = os.Getenv("MAILOUTBOXTABLE")
+	AUDITTABLE               = os.Getenv("AUDITTABLE")
+	FRIENDTABLE              = os.Getenv("FRIENDTABLE")
+	USERPOOLID               = os.Getenv("USERPOOLID")
+	AVATARBUCKET             = os.Getenv("AVATARBUCKET")
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatalf("ERROR INITIALIZATION: %v\n", err)
+	}
+}
+
+func run() error {
+	awsConfig, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(REGION))
+	if err != nil {
+		return fmt.Errorf("failed to load aws config: %v", err)
+	}
+	dynamoClient := dynamodb.NewFromConfig(awsConfig)
+	cognitoClient := cognitoidentityprovider.NewFromConfig(awsConfig)
+	s3Client := s3.NewFromConfig(awsConfig)
+
+	if USERPOOLID == "" {
+		return fmt.Errorf("no USERPOOLID provided")
+	}
+
+	lambda.Start(DeleteHandler(dynamoClient, cognitoClient, s3Client))
+	return nil
+}
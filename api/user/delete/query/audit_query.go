@@ -0,0 +1,41 @@
+package query
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// FetchAuditSubjects fetches the references of the subject to the audit records.
+func FetchAuditSubjects(dynamoClient *dynamodb.Client, ctx context.Context, tableName, subject string) ([]AuditSubjectOutput, error) {
+	auditSubjects := []AuditSubjectOutput{}
+	var lastEvaluatedKey map[string]types.AttributeValue
+	for {
+		output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
+			TableName: aws.String(tableName),
+			IndexName: aws.String("subject_gsi"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":subject": &types.AttributeValueMemberS{Value: subject},
+			},
+			KeyConditionExpression: aws.String("subject = :subject"),
+			ExclusiveStartKey:      lastEvaluatedKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		var page []AuditSubjectOutput
+		if err := attributevalue.UnmarshalListOfMaps(output.Items, &page); err != nil {
+			return nil, err
+		}
+		auditSubjects = append(auditSubjects, page...)
+
+		lastEvaluatedKey = output.LastEvaluatedKey
+		if len(lastEvaluatedKey) < 1 {
+			break
+		}
+	}
+	return auditSubjects, nil
+}
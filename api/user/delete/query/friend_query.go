@@ -0,0 +1,44 @@
+package query
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// FetchFriendSubjects fetches the subjects of all friends and pending friend requests of the subject.
+func FetchFriendSubjects(dynamoClient *dynamodb.Client, ctx context.Context, tableName, subject string) ([]string, error) {
+	friendSubjects := []string{}
+	var lastEvaluatedKey map[string]types.AttributeValue
+	for {
+		output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
+			TableName: aws.String(tableName),
+			ExpressionAttributeNames: map[string]string{
+				"#subject":        "subject",
+				"#friend_subject": "friend_subject",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":subject": &types.AttributeValueMemberS{Value: subject},
+			},
+			KeyConditionExpression: aws.String("#subject = :subject"),
+			ProjectionExpression:   aws.String("#friend_subject"),
+			ExclusiveStartKey:      lastEvaluatedKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range output.Items {
+			if friendSubject, ok := item["friend_subject"].(*types.AttributeValueMemberS); ok {
+				friendSubjects = append(friendSubjects, friendSubject.Value)
+			}
+		}
+
+		lastEvaluatedKey = output.LastEvaluatedKey
+		if len(lastEvaluatedKey) < 1 {
+			break
+		}
+	}
+	return friendSubjects, nil
+}
@@ -0,0 +1,42 @@
+package query
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// FetchUsernameHistory fetches all usernames recorded for the subject.
+// usernames that were taken over by another user afterwards are recorded for the other user.
+func FetchUsernameHistory(dynamoClient *dynamodb.Client, ctx context.Context, tableName, subject string) ([]UsernameOutput, error) {
+	usernames := []UsernameOutput{}
+	var lastEvaluatedKey map[string]types.AttributeValue
+	for {
+		output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
+			TableName: aws.String(tableName),
+			IndexName: aws.String("subject_gsi"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":subject": &types.AttributeValueMemberS{Value: subject},
+			},
+			KeyConditionExpression: aws.String("subject = :subject"),
+			ExclusiveStartKey:      lastEvaluatedKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		var page []UsernameOutput
+		if err := attributevalue.UnmarshalListOfMaps(output.Items, &page); err != nil {
+			return nil, err
+		}
+		usernames = append(usernames, page...)
+
+		lastEvaluatedKey = output.LastEvaluatedKey
+		if len(lastEvaluatedKey) < 1 {
+			break
+		}
+	}
+	return usernames, nil
+}
@@ -0,0 +1,73 @@
+package query
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// FetchMailJobs fetches the mail jobs of the subject via the participant_gsi.
+// jobs of legacy games are keyed by the username, they are only included for the games of the participations
+// (usernames can be taken over by other users).
+func FetchMailJobs(dynamoClient *dynamodb.Client, ctx context.Context, tableName, subject string, participations []ParticipationOutput) ([]MailJobOutput, error) {
+	mailJobs, err := fetchParticipantMailJobs(dynamoClient, ctx, tableName, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	legacyGames := map[string]map[string]bool{}
+	for _, participation := range participations {
+		if participation.Username == "" || participation.Username == subject {
+			continue
+		}
+		if _, ok := legacyGames[participation.Username]; !ok {
+			legacyGames[participation.Username] = map[string]bool{}
+		}
+		legacyGames[participation.Username][participation.GameId] = true
+	}
+	for username, gameIds := range legacyGames {
+		legacyMailJobs, err := fetchParticipantMailJobs(dynamoClient, ctx, tableName, username)
+		if err != nil {
+			return nil, err
+		}
+		for _, mailJob := range legacyMailJobs {
+			if gameIds[mailJob.GameId] {
+				mailJobs = append(mailJobs, mailJob)
+			}
+		}
+	}
+	return mailJobs, nil
+}
+
+func fetchParticipantMailJobs(dynamoClient *dynamodb.Client, ctx context.Context, tableName, participant string) ([]MailJobOutput, error) {
+	mailJobs := []MailJobOutput{}
+	var lastEvaluatedKey map[string]types.AttributeValue
+	for {
+		output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
+			TableName: aws.String(tableName),
+			IndexName: aws.String("participant_gsi"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":participant": &types.AttributeValueMemberS{Value: participant},
+			},
+			KeyConditionExpression: aws.String("participant = :participant"),
+			ExclusiveStartKey:      lastEvaluatedKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		var page []MailJobOutput
+		if err := attributevalue.UnmarshalListOfMaps(output.Items, &page); err != nil {
+			return nil, err
+		}
+		mailJobs = append(mailJobs, page...)
+
+		lastEvaluatedKey = output.LastEvaluatedKey
+		if len(lastEvaluatedKey) < 1 {
+			break
+		}
+	}
+	return mailJobs, nil
+}
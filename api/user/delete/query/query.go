@@ -0,0 +1,32 @@
+// contains wrappers for database query functions.
+// main purpose is to abstract some boilerplate code
+// away from the handler.
+package query
+
+type ParticipationOutput struct {
+	SortKey  string `dynamodbav:"played_at_gameid"`
+	GameId   string `dynamodbav:"gameid"`
+	Username string `dynamodbav:"username"`
+}
+
+type UsernameOutput struct {
+	Username string `dynamodbav:"username"`
+}
+
+type UserOutput struct {
+	Subject  string `dynamodbav:"subject"`
+	Username string `dynamodbav:"username"`
+	AvatarId string `dynamodbav:"avatar_id"`
+}
+
+// AuditSubjectOutput references an audit record from a subject of the game.
+type AuditSubjectOutput struct {
+	GameId string `dynamodbav:"gameid"`
+	Event  string `dynamodbav:"audit_event"`
+	Ref    string `dynamodbav:"audit_ref"`
+}
+
+type MailJobOutput struct {
+	JobId  string `dynamodbav:"jobid"`
+	GameId string `dynamodbav:"gameid"`
+}
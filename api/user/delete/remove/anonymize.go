@@ -0,0 +1,198 @@
+package remove
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// username displayed for the anonymized participant entries.
+	ANONYMOUS_USERNAME = "[deleted]"
+)
+
+// ErrConcurrentUpdate indicates that the game was modified while the participant was anonymized.
+var ErrConcurrentUpdate = errors.New("game was modified concurrently, please retry")
+
+// AnonymizeParticipant replaces the entry of the subject in the game (and in the previous versions of the game) with an anonymous entry.
+// the anonymous entry keeps the results (placement, points, elo), so that the game stays consistent for the other participants.
+// the entry is marked as deleted, its subject and map key are replaced by a random anonymous id (the same id is set as submitter if the subject submitted the game).
+// legacy games are keyed by the username, therefore the username of the participation is used if the entry has no subject.
+// if the game does not exist anymore, nothing is updated.
+func AnonymizeParticipant(dynamoClient *dynamodb.Client, ctx context.Context, tableName, gameid, subject, username string) error {
+	output, err := dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"gameid": &types.AttributeValueMemberS{Value: gameid},
+		},
+		ConsistentRead:       aws.Bool(true),
+		ProjectionExpression: aws.String("#participants, #history, #submitter"),
+		ExpressionAttributeNames: map[string]string{
+			"#participants": "participants",
+			"#history":      "history",
+			"#submitter":    "submitter",
+		},
+	})
+	if err != nil {
+		return err
+	}
+	participants, ok := output.Item["participants"].(*types.AttributeValueMemberM)
+	if !ok {
+		return nil
+	}
+
+	anonymousId, err := generateAnonymousId()
+	if err != nil {
+		return fmt.Errorf("failed to generate anonymous id: %v", err)
+	}
+
+	expressionAttributeNames := map[string]string{
+		"#participants": "participants",
+	}
+	expressionAttributeValues := map[string]types.AttributeValue{
+		":previous_participants": participants,
+	}
+	updateExpression := ""
+	anonymized := false
+
+	if newParticipants, ok := anonymizeParticipants(participants, subject, username, anonymousId); ok {
+		expressionAttributeValues[":participants"] = newParticipants
+		updateExpression = "SET #participants = :participants"
+		anonymized = true
+	}
+
+	if history, ok := output.Item["history"].(*types.AttributeValueMemberL); ok {
+		newHistory := &types.AttributeValueMemberL{Value: []types.AttributeValue{}}
+		historyAnonymized := false
+		for _, version := range history.Value {
+			versionMap, ok := version.(*types.AttributeValueMemberM)
+			if !ok {
+				newHistory.Value = append(newHistory.Value, version)
+				continue
+			}
+			versionParticipants, ok := versionMap.Value["participants"].(*types.AttributeValueMemberM)
+			if !ok {
+				newHistory.Value = append(newHistory.Value, version)
+				continue
+			}
+			newVersionParticipants, ok := anonymizeParticipants(versionParticipants, subject, username, anonymousId)
+			if !ok {
+				newHistory.Value = append(newHistory.Value, version)
+				continue
+			}
+			newVersion := &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{}}
+			for key, value := range versionMap.Value {
+				newVersion.Value[key] = value
+			}
+			newVersion.Value["participants"] = newVersionParticipants
+			newHistory.Value = append(newHistory.Value, newVersion)
+			historyAnonymized = true
+		}
+		if historyAnonymized {
+			expressionAttributeNames["#history"] = "history"
+			expressionAttributeValues[":history"] = newHistory
+			updateExpression = appendSetExpression(updateExpression, "#history = :history")
+			anonymized = true
+		}
+	}
+
+	if submitter, ok := output.Item["submitter"].(*types.AttributeValueMemberS); ok && submitter.Value == subject {
+		expressionAttributeNames["#submitter"] = "submitter"
+		expressionAttributeValues[":submitter"] = &types.AttributeValueMemberS{Value: anonymousId}
+		updateExpression = appendSetExpression(updateExpression, "#submitter = :submitter")
+		anonymized = true
+	}
+
+	if !anonymized {
+		return nil
+	}
+
+	_, err = dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"gameid": &types.AttributeValueMemberS{Value: gameid},
+		},
+		ExpressionAttributeNames:  expressionAttributeNames,
+		ExpressionAttributeValues: expressionAttributeValues,
+		// the participants are compared as a whole, this ensures that no edit or confirmation happened in the meantime.
+		ConditionExpression: aws.String("#participants = :previous_participants"),
+		UpdateExpression:    aws.String(updateExpression),
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return ErrConcurrentUpdate
+		}
+		return err
+	}
+	return nil
+}
+
+// anonymizeParticipants returns a copy of the participants where the entry of the subject is replaced with an anonymous entry.
+// false is returned if the participants contain no entry of the subject.
+func anonymizeParticipants(participants *types.AttributeValueMemberM, subject, username, anonymousId string) (*types.AttributeValueMemberM, bool) {
+	participantKey := ""
+	for key, value := range participants.Value {
+		entry, ok := value.(*types.AttributeValueMemberM)
+		if !ok {
+			continue
+		}
+		if entrySubject, ok := entry.Value["subject"].(*types.AttributeValueMemberS); ok && entrySubject.Value != "" {
+			if entrySubject.Value == subject {
+				participantKey = key
+				break
+			}
+		} else if key == username {
+			participantKey = key
+			break
+		}
+	}
+	if participantKey == "" {
+		return nil, false
+	}
+
+	newParticipants := &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{}}
+	for key, value := range participants.Value {
+		if key != participantKey {
+			newParticipants.Value[key] = value
+		}
+	}
+	entry := participants.Value[participantKey].(*types.AttributeValueMemberM)
+	newEntry := &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{}}
+	for key, value := range entry.Value {
+		switch key {
+		case "confirm_secret", "mail_status":
+			// secrets and delivery state are personal and not required to keep the game consistent.
+			continue
+		default:
+			newEntry.Value[key] = value
+		}
+	}
+	newEntry.Value["subject"] = &types.AttributeValueMemberS{Value: anonymousId}
+	newEntry.Value["username"] = &types.AttributeValueMemberS{Value: ANONYMOUS_USERNAME}
+	newEntry.Value["deleted"] = &types.AttributeValueMemberBOOL{Value: true}
+	newParticipants.Value[anonymousId] = newEntry
+	return newParticipants, true
+}
+
+func appendSetExpression(updateExpression, setExpression string) string {
+	if updateExpression == "" {
+		return "SET " + setExpression
+	}
+	return updateExpression + ", " + setExpression
+}
+
+// generateAnonymousId generates a random id used as subject and key of an anonymized entry.
+func generateAnonymousId() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return "deleted-" + hex.EncodeToString(id), nil
+}
@@ -0,0 +1,120 @@
+package remove
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// AnonymizeAudit replaces the entry of the subject in the audit record with an anonymous entry (like in the games)
+// and deletes the reference of the subject to the record. the username of the subject is replaced in the unconfirmed list,
+// the submitter is replaced by the anonymous id. if the record does not exist anymore, only the reference is deleted.
+func AnonymizeAudit(dynamoClient *dynamodb.Client, ctx context.Context, tableName, gameid, event, ref, subject string) error {
+	output, err := dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"gameid":      &types.AttributeValueMemberS{Value: gameid},
+			"audit_event": &types.AttributeValueMemberS{Value: ref},
+		},
+		ConsistentRead:       aws.Bool(true),
+		ProjectionExpression: aws.String("#participants, #unconfirmed, #submitter"),
+		ExpressionAttributeNames: map[string]string{
+			"#participants": "participants",
+			"#unconfirmed":  "unconfirmed",
+			"#submitter":    "submitter",
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if participants, ok := output.Item["participants"].(*types.AttributeValueMemberM); ok {
+		anonymousId, err := generateAnonymousId()
+		if err != nil {
+			return fmt.Errorf("failed to generate anonymous id: %v", err)
+		}
+
+		expressionAttributeNames := map[string]string{
+			"#participants": "participants",
+		}
+		expressionAttributeValues := map[string]types.AttributeValue{
+			":previous_participants": participants,
+		}
+		updateExpression := ""
+
+		username := ""
+		for _, value := range participants.Value {
+			entry, ok := value.(*types.AttributeValueMemberM)
+			if !ok {
+				continue
+			}
+			if entrySubject, ok := entry.Value["subject"].(*types.AttributeValueMemberS); ok && entrySubject.Value == subject {
+				if entryUsername, ok := entry.Value["username"].(*types.AttributeValueMemberS); ok {
+					username = entryUsername.Value
+				}
+				break
+			}
+		}
+		// audit entries always contain the subject, the username is only used to find entries of legacy games.
+		if newParticipants, ok := anonymizeParticipants(participants, subject, "", anonymousId); ok {
+			expressionAttributeValues[":participants"] = newParticipants
+			updateExpression = "SET #participants = :participants"
+		}
+
+		if unconfirmed, ok := output.Item["unconfirmed"].(*types.AttributeValueMemberL); ok && username != "" {
+			newUnconfirmed := &types.AttributeValueMemberL{Value: []types.AttributeValue{}}
+			for _, value := range unconfirmed.Value {
+				if entry, ok := value.(*types.AttributeValueMemberS); ok && entry.Value == username {
+					value = &types.AttributeValueMemberS{Value: ANONYMOUS_USERNAME}
+				}
+				newUnconfirmed.Value = append(newUnconfirmed.Value, value)
+			}
+			expressionAttributeNames["#unconfirmed"] = "unconfirmed"
+			expressionAttributeValues[":unconfirmed"] = newUnconfirmed
+			updateExpression = appendSetExpression(updateExpression, "#unconfirmed = :unconfirmed")
+		}
+
+		if submitter, ok := output.Item["submitter"].(*types.AttributeValueMemberS); ok && submitter.Value == subject {
+			expressionAttributeNames["#submitter"] = "submitter"
+			expressionAttributeValues[":submitter"] = &types.AttributeValueMemberS{Value: anonymousId}
+			updateExpression = appendSetExpression(updateExpression, "#submitter = :submitter")
+		}
+
+		if updateExpression != "" {
+			_, err = dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+				TableName: aws.String(tableName),
+				Key: map[string]types.AttributeValue{
+					"gameid":      &types.AttributeValueMemberS{Value: gameid},
+					"audit_event": &types.AttributeValueMemberS{Value: ref},
+				},
+				ExpressionAttributeNames:  expressionAttributeNames,
+				ExpressionAttributeValues: expressionAttributeValues,
+				ConditionExpression:       aws.String("#participants = :previous_participants"),
+				UpdateExpression:          aws.String(updateExpression),
+			})
+			if err != nil {
+				var condErr *types.ConditionalCheckFailedException
+				if errors.As(err, &condErr) {
+					return ErrConcurrentUpdate
+				}
+				return err
+			}
+		}
+	}
+
+	_, err = dynamoClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"gameid":      &types.AttributeValueMemberS{Value: gameid},
+			"audit_event": &types.AttributeValueMemberS{Value: event},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package remove
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// sizes of the avatar thumbnails generated by the thumbnail function.
+var AVATAR_SIZES = []int{64, 256}
+
+// DeleteAvatar deletes the thumbnails of the avatar id recorded on the user by the thumbnail function.
+// the id is never parsed from the iconurl, it could reference the avatar of another user.
+func DeleteAvatar(s3Client *s3.Client, ctx context.Context, bucketName, avatarId string) error {
+	if avatarId == "" {
+		return nil
+	}
+
+	objects := []types.ObjectIdentifier{}
+	for _, size := range AVATAR_SIZES {
+		objects = append(objects, types.ObjectIdentifier{
+			Key: aws.String(fmt.Sprintf("avatars/%s-%d.png", avatarId, size)),
+		})
+	}
+	output, err := s3Client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+		Bucket: aws.String(bucketName),
+		Delete: &types.Delete{
+			Objects: objects,
+			Quiet:   aws.Bool(true),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if len(output.Errors) > 0 {
+		return fmt.Errorf("failed to delete %s: %s", aws.ToString(output.Errors[0].Key), aws.ToString(output.Errors[0].Message))
+	}
+	return nil
+}
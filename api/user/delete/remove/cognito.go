@@ -0,0 +1,27 @@
+package remove
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+)
+
+// DeleteCognitoUser deletes the account of the user from the user pool.
+// if the account was already deleted, no error is returned.
+func DeleteCognitoUser(cognitoClient *cognitoidentityprovider.Client, ctx context.Context, userPoolId, username string) error {
+	_, err := cognitoClient.AdminDeleteUser(ctx, &cognitoidentityprovider.AdminDeleteUserInput{
+		UserPoolId: aws.String(userPoolId),
+		Username:   aws.String(username),
+	})
+	if err != nil {
+		var notFoundErr *types.UserNotFoundException
+		if errors.As(err, &notFoundErr) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+// contains wrappers for database delete functions.
+// main purpose is to abstract some boilerplate code
+// away from the handler.
+package remove
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DeleteParticipation deletes the participation of the subject.
+func DeleteParticipation(dynamoClient *dynamodb.Client, ctx context.Context, tableName, subject, sortKey string) error {
+	_, err := dynamoClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"subject":          &types.AttributeValueMemberS{Value: subject},
+			"played_at_gameid": &types.AttributeValueMemberS{Value: sortKey},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteMailJobs deletes the mail jobs of the participant.
+func DeleteMailJobs(dynamoClient *dynamodb.Client, ctx context.Context, tableName string, jobIds []string) error {
+	for _, jobId := range jobIds {
+		_, err := dynamoClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName: aws.String(tableName),
+			Key: map[string]types.AttributeValue{
+				"jobid": &types.AttributeValueMemberS{Value: jobId},
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteUsername deletes a username entry (history or reservation) if it is held by the subject.
+// reservations are keyed by the normalized username (see NormalizeUsername).
+func DeleteUsername(dynamoClient *dynamodb.Client, ctx context.Context, tableName, username, subject string) error {
+	_, err := dynamoClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"username": &types.AttributeValueMemberS{Value: username},
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":subject": &types.AttributeValueMemberS{Value: subject},
+		},
+		// usernames taken over by another user are kept.
+		ConditionExpression: aws.String("subject = :subject"),
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteFriendship deletes the relation of the subject to the friend and the relation of the friend to the subject.
+// the friend relation is deleted first, so that a failed deletion can be retried from the subject's relations.
+func DeleteFriendship(dynamoClient *dynamodb.Client, ctx context.Context, tableName, subject, friendSubject string) error {
+	for _, key := range []map[string]types.AttributeValue{
+		{
+			"subject":        &types.AttributeValueMemberS{Value: friendSubject},
+			"friend_subject": &types.AttributeValueMemberS{Value: subject},
+		},
+		{
+			"subject":        &types.AttributeValueMemberS{Value: subject},
+			"friend_subject": &types.AttributeValueMemberS{Value: friendSubject},
+		},
+	} {
+		_, err := dynamoClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName: aws.String(tableName),
+			Key:       key,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteUser deletes the user item of the subject.
+func DeleteUser(dynamoClient *dynamodb.Client, ctx context.Context, tableName, subject string) error {
+	_, err := dynamoClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"subject": &types.AttributeValueMemberS{Value: subject},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// NormalizeUsername normalizes the username like the user update does for the reservation key.
+// the username is lowercased and whitespace is collapsed to single spaces.
+func NormalizeUsername(username string) string {
+	return strings.Join(strings.Fields(strings.ToLower(username)), " ")
+}
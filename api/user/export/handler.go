@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/megakuul/leaderboard/api/user/export/query"
+)
+
+type ExportResponse struct {
+	Message         string                      `json:"message"`
+	User            map[string]interface{}      `json:"user"`
+	UsernameHistory []query.UsernameOutput      `json:"username_history"`
+	Participations  []query.ParticipationOutput `json:"participations"`
+	Confirmations   []query.ConfirmationOutput  `json:"confirmations"`
+	MailJobs        []query.MailJobOutput       `json:"mail_jobs"`
+	Audits          []query.AuditOutput         `json:"audits"`
+	Friends         []query.FriendOutput        `json:"friends"`
+}
+
+func ExportHandler(dynamoClient *dynamodb.Client) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		response, code, err := runExportHandler(dynamoClient, &request, ctx)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: code,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       err.Error(),
+			}, nil
+		}
+		serializedResponse, err := json.Marshal(&response)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: http.StatusInternalServerError,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       "failed to serialize response",
+			}, nil
+		}
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode: code,
+			Headers: map[string]string{
+				"Content-Type":        "application/json",
+				"Content-Disposition": "attachment; filename=\"leaderboard-export.json\"",
+				"Cache-Control":       "no-store",
+			},
+			Body: string(serializedResponse),
+		}, nil
+	}
+}
+
+func runExportHandler(dynamoClient *dynamodb.Client, request *events.APIGatewayV2HTTPRequest, ctx context.Context) (*ExportResponse, int, error) {
+	sub := request.RequestContext.Authorizer.JWT.Claims["sub"]
+	if sub == "" {
+		return nil, http.StatusUnprocessableEntity, fmt.Errorf("invalid sub claim in the ID token")
+	}
+
+	user, err := query.FetchUser(dynamoClient, ctx, USERTABLE, sub)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch user: %v", err)
+	}
+
+	usernameHistory, err := query.FetchUsernameHistory(dynamoClient, ctx, USERNAMEHISTORYTABLE, sub)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch username history: %v", err)
+	}
+
+	participations, err := query.FetchParticipations(dynamoClient, ctx, PARTICIPATIONTABLE, sub)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch participations: %v", err)
+	}
+
+	confirmations, err := query.FetchConfirmations(dynamoClient, ctx, GAMETABLE, sub, participations)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch confirmations: %v", err)
+	}
+
+	mailJobs, err := query.FetchMailJobs(dynamoClient, ctx, MAILOUTBOXTABLE, sub, participations)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch mail jobs: %v", err)
+	}
+
+	audits, err := query.FetchAudits(dynamoClient, ctx, AUDITTABLE, sub)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch audit records: %v", err)
+	}
+
+	friends, err := query.FetchFriends(dynamoClient, ctx, FRIENDTABLE, USERTABLE, sub)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch friends: %v", err)
+	}
+
+	return &ExportResponse{
+		Message:         "successfully exported user data",
+		User:            user,
+		UsernameHistory: usernameHistory,
+		Participations:  participations,
+		Confirmations:   confirmations,
+		MailJobs:        mailJobs,
+		Audits:          audits,
+		Friends:         friends,
+	}, http.StatusOK, nil
+}
@@ -0,0 +1,89 @@
+package query
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type auditSubjectOutput struct {
+	GameId string `dynamodbav:"gameid"`
+	Ref    string `dynamodbav:"audit_ref"`
+}
+
+type auditOutput struct {
+	GameId       string                            `dynamodbav:"gameid"`
+	Event        string                            `dynamodbav:"audit_event"`
+	Time         int                               `dynamodbav:"audit_time"`
+	Date         string                            `dynamodbav:"game_date"`
+	Submitter    string                            `dynamodbav:"submitter"`
+	Participants map[string]map[string]interface{} `dynamodbav:"participants"`
+}
+
+// FetchAudits fetches the entry of the subject in every audit record that references the subject.
+// audit records are removed after the audit retention period.
+func FetchAudits(dynamoClient *dynamodb.Client, ctx context.Context, tableName, subject string) ([]AuditOutput, error) {
+	keys := []map[string]types.AttributeValue{}
+	var lastEvaluatedKey map[string]types.AttributeValue
+	for {
+		output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
+			TableName: aws.String(tableName),
+			IndexName: aws.String("subject_gsi"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":subject": &types.AttributeValueMemberS{Value: subject},
+			},
+			KeyConditionExpression: aws.String("subject = :subject"),
+			ExclusiveStartKey:      lastEvaluatedKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		var page []auditSubjectOutput
+		if err := attributevalue.UnmarshalListOfMaps(output.Items, &page); err != nil {
+			return nil, err
+		}
+		for _, auditSubject := range page {
+			keys = append(keys, map[string]types.AttributeValue{
+				"gameid":      &types.AttributeValueMemberS{Value: auditSubject.GameId},
+				"audit_event": &types.AttributeValueMemberS{Value: auditSubject.Ref},
+			})
+		}
+
+		lastEvaluatedKey = output.LastEvaluatedKey
+		if len(lastEvaluatedKey) < 1 {
+			break
+		}
+	}
+
+	items, err := batchGetItems(dynamoClient, ctx, tableName, keys)
+	if err != nil {
+		return nil, err
+	}
+	var records []auditOutput
+	if err := attributevalue.UnmarshalListOfMaps(items, &records); err != nil {
+		return nil, err
+	}
+
+	audits := []AuditOutput{}
+	for _, record := range records {
+		var participant map[string]interface{}
+		for _, entry := range record.Participants {
+			if entry["subject"] == subject {
+				participant = entry
+				break
+			}
+		}
+		audits = append(audits, AuditOutput{
+			GameId:      record.GameId,
+			Event:       record.Event,
+			Time:        record.Time,
+			Date:        record.Date,
+			Submitted:   record.Submitter == subject,
+			Participant: participant,
+		})
+	}
+	return audits, nil
+}
@@ -0,0 +1,73 @@
+package query
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type gameOutput struct {
+	GameId       string                            `dynamodbav:"gameid"`
+	Date         string                            `dynamodbav:"game_date"`
+	PlayedAt     string                            `dynamodbav:"played_at"`
+	Name         string                            `dynamodbav:"name"`
+	Location     string                            `dynamodbav:"location"`
+	Readonly     bool                              `dynamodbav:"readonly"`
+	Reverted     bool                              `dynamodbav:"reverted"`
+	Submitter    string                            `dynamodbav:"submitter"`
+	Participants map[string]map[string]interface{} `dynamodbav:"participants"`
+}
+
+// FetchConfirmations fetches the entry of the subject in every game of the participations.
+// games that no longer exist (e.g. expired games) are skipped. the confirmation secret is not exported.
+func FetchConfirmations(dynamoClient *dynamodb.Client, ctx context.Context, tableName, subject string, participations []ParticipationOutput) ([]ConfirmationOutput, error) {
+	keys := []map[string]types.AttributeValue{}
+	for _, participation := range participations {
+		keys = append(keys, map[string]types.AttributeValue{
+			"gameid": &types.AttributeValueMemberS{Value: participation.GameId},
+		})
+	}
+	items, err := batchGetItems(dynamoClient, ctx, tableName, keys)
+	if err != nil {
+		return nil, err
+	}
+	var games []gameOutput
+	if err := attributevalue.UnmarshalListOfMaps(items, &games); err != nil {
+		return nil, err
+	}
+	gamesById := map[string]gameOutput{}
+	for _, game := range games {
+		gamesById[game.GameId] = game
+	}
+
+	confirmations := []ConfirmationOutput{}
+	for _, participation := range participations {
+		game, ok := gamesById[participation.GameId]
+		if !ok {
+			continue
+		}
+		// legacy games are keyed by the username and may not contain the subject of the participant.
+		participant, ok := game.Participants[subject]
+		if !ok {
+			participant, ok = game.Participants[participation.Username]
+			if !ok || (participant["subject"] != nil && participant["subject"] != subject) {
+				continue
+			}
+		}
+		delete(participant, "confirm_secret")
+		confirmations = append(confirmations, ConfirmationOutput{
+			GameId:      game.GameId,
+			Date:        game.Date,
+			PlayedAt:    game.PlayedAt,
+			Name:        game.Name,
+			Location:    game.Location,
+			Readonly:    game.Readonly,
+			Reverted:    game.Reverted,
+			Submitted:   game.Submitter == subject,
+			Participant: participant,
+		})
+	}
+	return confirmations, nil
+}
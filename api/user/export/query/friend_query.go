@@ -0,0 +1,67 @@
+package query
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// FetchFriends fetches the friends and pending friend requests of the subject with the current usernames of the friends.
+// only the username of the friend is exported, relations of deleted friends are skipped.
+func FetchFriends(dynamoClient *dynamodb.Client, ctx context.Context, friendTableName, userTableName, subject string) ([]FriendOutput, error) {
+	relations := []FriendOutput{}
+	var lastEvaluatedKey map[string]types.AttributeValue
+	for {
+		output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
+			TableName: aws.String(friendTableName),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":subject": &types.AttributeValueMemberS{Value: subject},
+			},
+			KeyConditionExpression: aws.String("subject = :subject"),
+			ExclusiveStartKey:      lastEvaluatedKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		var page []FriendOutput
+		if err := attributevalue.UnmarshalListOfMaps(output.Items, &page); err != nil {
+			return nil, err
+		}
+		relations = append(relations, page...)
+
+		lastEvaluatedKey = output.LastEvaluatedKey
+		if len(lastEvaluatedKey) < 1 {
+			break
+		}
+	}
+
+	keys := []map[string]types.AttributeValue{}
+	for _, relation := range relations {
+		keys = append(keys, map[string]types.AttributeValue{
+			"subject": &types.AttributeValueMemberS{Value: relation.FriendSubject},
+		})
+	}
+	items, err := batchGetItems(dynamoClient, ctx, userTableName, keys)
+	if err != nil {
+		return nil, err
+	}
+	usernames := map[string]string{}
+	for _, item := range items {
+		subject, subjectOk := item["subject"].(*types.AttributeValueMemberS)
+		username, usernameOk := item["username"].(*types.AttributeValueMemberS)
+		if subjectOk && usernameOk {
+			usernames[subject.Value] = username.Value
+		}
+	}
+
+	friends := []FriendOutput{}
+	for _, relation := range relations {
+		if relation.Username = usernames[relation.FriendSubject]; relation.Username != "" {
+			friends = append(friends, relation)
+		}
+	}
+	return friends, nil
+}
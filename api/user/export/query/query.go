@@ -0,0 +1,65 @@
+// contains wrappers for database query functions.
+// main purpose is to abstract some boilerplate code
+// away from the handler.
+package query
+
+const (
+	// maximum number of keys dynamodb accepts in one batch get.
+	MAX_BATCH_GET = 100
+)
+
+// ParticipationOutput is an entry of the participant index of the user.
+type ParticipationOutput struct {
+	SortKey  string `dynamodbav:"played_at_gameid" json:"played_at_gameid"`
+	GameId   string `dynamodbav:"gameid" json:"gameid"`
+	Username string `dynamodbav:"username" json:"username"`
+	Date     string `dynamodbav:"game_date" json:"date"`
+}
+
+// UsernameOutput is an entry of the username history of the user.
+type UsernameOutput struct {
+	Username   string `dynamodbav:"username" json:"username"`
+	RecordedAt int    `dynamodbav:"recorded_at" json:"recorded_at"`
+}
+
+// ConfirmationOutput contains the entry of the user in a game, including the confirmation state.
+type ConfirmationOutput struct {
+	GameId      string                 `json:"gameid"`
+	Date        string                 `json:"date"`
+	PlayedAt    string                 `json:"played_at"`
+	Name        string                 `json:"name,omitempty"`
+	Location    string                 `json:"location,omitempty"`
+	Readonly    bool                   `json:"readonly"`
+	Reverted    bool                   `json:"reverted"`
+	Submitted   bool                   `json:"submitted"`
+	Participant map[string]interface{} `json:"participant"`
+}
+
+// AuditOutput contains the entry of the user in the audit record of a game (e.g. an expired game).
+type AuditOutput struct {
+	GameId      string                 `json:"gameid"`
+	Event       string                 `json:"event"`
+	Time        int                    `json:"time"`
+	Date        string                 `json:"date"`
+	Submitted   bool                   `json:"submitted"`
+	Participant map[string]interface{} `json:"participant,omitempty"`
+}
+
+// MailJobOutput is a mail sent to the user (confirmation, reminder or expiration).
+type MailJobOutput struct {
+	JobId     string `dynamodbav:"jobid" json:"jobid"`
+	Kind      string `dynamodbav:"kind" json:"kind,omitempty"`
+	GameId    string `dynamodbav:"gameid" json:"gameid"`
+	Email     string `dynamodbav:"email" json:"email"`
+	Status    string `dynamodbav:"status" json:"status"`
+	Attempts  int    `dynamodbav:"attempts" json:"attempts"`
+	LastError string `dynamodbav:"last_error" json:"last_error,omitempty"`
+}
+
+// FriendOutput is a friend or a pending friend request of the user.
+type FriendOutput struct {
+	FriendSubject string `dynamodbav:"friend_subject" json:"-"`
+	Username      string `dynamodbav:"-" json:"username"`
+	Status        string `dynamodbav:"status" json:"status"`
+	UpdatedAt     int64  `dynamodbav:"updated_at" json:"updated_at"`
+}
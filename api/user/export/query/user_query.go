@@ -0,0 +1,31 @@
+package query
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// FetchUser fetches the complete user item of the subject. nil is returned if the subject has no user.
+func FetchUser(dynamoClient *dynamodb.Client, ctx context.Context, tableName, subject string) (map[string]interface{}, error) {
+	output, err := dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"subject": &types.AttributeValueMemberS{Value: subject},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if output.Item == nil {
+		return nil, nil
+	}
+	var user map[string]interface{}
+	if err := attributevalue.UnmarshalMap(output.Item, &user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
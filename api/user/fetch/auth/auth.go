@@ -0,0 +1,212 @@
+// contains the verification of the cognito id tokens.
+// the fetch route is public (no authorizer), therefore the token of
+// personalized queries (e.g. the friends scope) is verified by the function itself.
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnauthorized indicates that the token is missing, malformed, expired or was not issued for this leaderboard.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// minimal interval between two jwks fetches, unknown key ids do not refetch the keys more often.
+const JWKS_REFRESH_INTERVAL = 5 * time.Minute
+
+// maximal duration of a jwks fetch, a stalled endpoint must not block the requests until the function times out.
+const JWKS_FETCH_TIMEOUT = 5 * time.Second
+
+var jwksClient = &http.Client{Timeout: JWKS_FETCH_TIMEOUT}
+
+// TokenVerifier verifies id tokens issued by the cognito user pool (issuer) for the user pool client (audience).
+// the public keys are fetched from the jwks endpoint of the issuer and cached.
+type TokenVerifier struct {
+	Issuer   string
+	Audience string
+
+	mutex     sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+	// refresh is closed when the running fetch completes, nil if no fetch is running.
+	refresh chan struct{}
+}
+
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+	KeyId     string `json:"kid"`
+}
+
+type tokenClaims struct {
+	Subject   string `json:"sub"`
+	Issuer    string `json:"iss"`
+	Audience  string `json:"aud"`
+	TokenUse  string `json:"token_use"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+type jwks struct {
+	Keys []struct {
+		KeyId     string `json:"kid"`
+		KeyType   string `json:"kty"`
+		Algorithm string `json:"alg"`
+		Modulus   string `json:"n"`
+		Exponent  string `json:"e"`
+	} `json:"keys"`
+}
+
+// VerifySubject verifies the bearer token of the authorization header and returns its subject.
+// every rejected token results in ErrUnauthorized.
+func (v *TokenVerifier) VerifySubject(ctx context.Context, authorization string) (string, error) {
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok || token == "" {
+		return "", fmt.Errorf("%w: missing bearer token", ErrUnauthorized)
+	}
+	segments := strings.Split(token, ".")
+	if len(segments) != 3 {
+		return "", fmt.Errorf("%w: malformed token", ErrUnauthorized)
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(segments[0], &header); err != nil {
+		return "", fmt.Errorf("%w: malformed token", ErrUnauthorized)
+	}
+	if header.Algorithm != "RS256" {
+		return "", fmt.Errorf("%w: unsupported token algorithm", ErrUnauthorized)
+	}
+	key, err := v.publicKey(ctx, header.KeyId)
+	if err != nil {
+		return "", err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(segments[2])
+	if err != nil {
+		return "", fmt.Errorf("%w: malformed token", ErrUnauthorized)
+	}
+	digest := sha256.Sum256([]byte(segments[0] + "." + segments[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return "", fmt.Errorf("%w: signature mismatch", ErrUnauthorized)
+	}
+
+	var claims tokenClaims
+	if err := decodeSegment(segments[1], &claims); err != nil {
+		return "", fmt.Errorf("%w: malformed token", ErrUnauthorized)
+	}
+	if claims.ExpiresAt < time.Now().Unix() {
+		return "", fmt.Errorf("%w: token has expired", ErrUnauthorized)
+	}
+	if claims.Issuer != v.Issuer || claims.Audience != v.Audience || claims.TokenUse != "id" {
+		return "", fmt.Errorf("%w: token was not issued for this leaderboard", ErrUnauthorized)
+	}
+	if claims.Subject == "" {
+		return "", fmt.Errorf("%w: invalid sub claim", ErrUnauthorized)
+	}
+	return claims.Subject, nil
+}
+
+// publicKey returns the cached key of the key id, the keys are refetched if the key id is unknown (key rotation).
+// the keys are fetched without holding the mutex, concurrent lookups wait for the running fetch instead.
+func (v *TokenVerifier) publicKey(ctx context.Context, keyId string) (*rsa.PublicKey, error) {
+	for {
+		v.mutex.Lock()
+		if key, ok := v.keys[keyId]; ok {
+			v.mutex.Unlock()
+			return key, nil
+		}
+		if refresh := v.refresh; refresh != nil {
+			v.mutex.Unlock()
+			select {
+			case <-refresh:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		if time.Since(v.fetchedAt) < JWKS_REFRESH_INTERVAL {
+			v.mutex.Unlock()
+			return nil, fmt.Errorf("%w: unknown signing key", ErrUnauthorized)
+		}
+		refresh := make(chan struct{})
+		v.refresh = refresh
+		v.mutex.Unlock()
+
+		keys, err := fetchKeys(ctx, v.Issuer+"/.well-known/jwks.json")
+
+		v.mutex.Lock()
+		if err == nil {
+			v.keys = keys
+			v.fetchedAt = time.Now()
+		}
+		v.refresh = nil
+		close(refresh)
+		key, ok := v.keys[keyId]
+		v.mutex.Unlock()
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch signing keys: %v", err)
+		}
+		if ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf("%w: unknown signing key", ErrUnauthorized)
+	}
+}
+
+// fetchKeys fetches the rsa signing keys of the jwks endpoint.
+func fetchKeys(ctx context.Context, url string) (map[string]*rsa.PublicKey, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := jwksClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+
+	var set jwks
+	if err := json.NewDecoder(response.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+	keys := map[string]*rsa.PublicKey{}
+	for _, key := range set.Keys {
+		if key.KeyType != "RSA" {
+			continue
+		}
+		modulus, err := base64.RawURLEncoding.DecodeString(key.Modulus)
+		if err != nil {
+			return nil, fmt.Errorf("malformed modulus of key %s", key.KeyId)
+		}
+		exponent, err := base64.RawURLEncoding.DecodeString(key.Exponent)
+		if err != nil {
+			return nil, fmt.Errorf("malformed exponent of key %s", key.KeyId)
+		}
+		keys[key.KeyId] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(modulus),
+			E: int(new(big.Int).SetBytes(exponent).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+// decodeSegment decodes a base64url encoded json segment of the token.
+func decodeSegment(segment string, v interface{}) error {
+	decoded, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(decoded, v)
+}
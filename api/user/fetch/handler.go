@@ -3,24 +3,27 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/megakuul/leaderboard/api/user/fetch/auth"
 	"github.com/megakuul/leaderboard/api/user/fetch/query"
 )
 
 type FetchResponse struct {
-	Message    string             `json:"message"`
-	NewPageKey string             `json:"newpagekey"`
-	Users      []query.UserOutput `json:"users"`
+	Message     string             `json:"message"`
+	NewPageKey  string             `json:"newpagekey"`
+	PrevPageKey string             `json:"prevpagekey"`
+	Users       []query.UserOutput `json:"users"`
 }
 
-func FetchHandler(dynamoClient *dynamodb.Client) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+func FetchHandler(dynamoClient *dynamodb.Client, pageKeySigner *query.PageKeySigner, iconPolicy *query.IconPolicy, tokenVerifier *auth.TokenVerifier) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
 	return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
-		response, code, err := runFetchHandler(dynamoClient, &request, ctx)
+		response, code, err := runFetchHandler(dynamoClient, pageKeySigner, tokenVerifier, &request, ctx)
 		if err != nil {
 			return events.APIGatewayV2HTTPResponse{
 				StatusCode: code,
@@ -28,6 +31,7 @@ func FetchHandler(dynamoClient *dynamodb.Client) func(context.Context, events.AP
 				Body:       err.Error(),
 			}, nil
 		}
+		iconPolicy.Sanitize(response.Users)
 		serializedResponse, err := json.Marshal(&response)
 		if err != nil {
 			return events.APIGatewayV2HTTPResponse{
@@ -44,7 +48,7 @@ func FetchHandler(dynamoClient *dynamodb.Client) func(context.Context, events.AP
 	}
 }
 
-func runFetchHandler(dynamoClient *dynamodb.Client, request *events.APIGatewayV2HTTPRequest, ctx context.Context) (*FetchResponse, int, error) {
+func runFetchHandler(dynamoClient *dynamodb.Client, pageKeySigner *query.PageKeySigner, tokenVerifier *auth.TokenVerifier, request *events.APIGatewayV2HTTPRequest, ctx context.Context) (*FetchResponse, int, error) {
 	region := request.QueryStringParameters["region"]
 	if region == "" {
 		region = REGION
@@ -67,32 +71,128 @@ func runFetchHandler(dynamoClient *dynamodb.Client, request *events.APIGatewayV2
 		if err != nil {
 			return nil, http.StatusNotFound, fmt.Errorf("failed to fetch data by username: %v", err)
 		}
+		// renamed users are still found by their previous usernames.
+		if len(users) < 1 {
+			users, err = query.FetchByPreviousUsername(dynamoClient, ctx, USERNAMEHISTORYTABLE, USERTABLE, username)
+			if err != nil {
+				return nil, http.StatusNotFound, fmt.Errorf("failed to fetch data by username: %v", err)
+			}
+		}
+		if err := query.AssignRank(dynamoClient, ctx, RANKCOUNTTABLE, users); err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to calculate rank: %v", err)
+		}
 		return &FetchResponse{
 			Message: "successfully fetched data by username",
 			Users:   users,
 		}, http.StatusOK, nil
 	}
 
-	elo := request.QueryStringParameters["elo"]
-	if elo != "" {
-		users, err := query.FetchByElo(dynamoClient, ctx, USERTABLE, int32(pageSize), region, elo)
+	// the friends scope ranks the caller against its friends, the caller is identified by the id token.
+	if request.QueryStringParameters["scope"] == "friends" {
+		subject, err := tokenVerifier.VerifySubject(ctx, request.Headers["authorization"])
+		if errors.Is(err, auth.ErrUnauthorized) {
+			return nil, http.StatusUnauthorized, fmt.Errorf("failed to fetch data by friends: %v", err)
+		} else if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch data by friends: %v", err)
+		}
+		users, err := query.FetchFriends(dynamoClient, ctx, FRIENDTABLE, USERTABLE, subject)
 		if err != nil {
-			return nil, http.StatusNotFound, fmt.Errorf("failed to fetch data by elo: %v", err)
+			return nil, http.StatusNotFound, fmt.Errorf("failed to fetch data by friends: %v", err)
 		}
 		return &FetchResponse{
-			Message: "successfully fetched data by elo",
+			Message: "successfully fetched data by friends",
 			Users:   users,
 		}, http.StatusOK, nil
 	}
 
-	users, newPageKey, err := query.FetchByPage(dynamoClient, ctx, USERTABLE, int32(pageSize), lastPageKey, region)
-	if err != nil {
+	// the global scope merges the leaderboards of all regions into one ranking.
+	if request.QueryStringParameters["scope"] == "global" {
+		users, newPageKey, err := query.FetchGlobalPage(dynamoClient, ctx, pageKeySigner, USERTABLE, int32(pageSize), lastPageKey, REGIONS)
+		if errors.Is(err, query.ErrInvalidPageKey) {
+			return nil, http.StatusBadRequest, fmt.Errorf("failed to fetch data by global page: %v", err)
+		} else if err != nil {
+			return nil, http.StatusNotFound, fmt.Errorf("failed to fetch data by global page: %v", err)
+		}
+		if err := query.AssignRanks(dynamoClient, ctx, RANKCOUNTTABLE, REGIONS, users); err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to calculate ranks: %v", err)
+		}
+		return &FetchResponse{
+			Message:    "successfully fetched data by global page",
+			NewPageKey: newPageKey,
+			Users:      users,
+		}, http.StatusOK, nil
+	}
+
+	// around shows the user together with the users directly above and below within the region of the user.
+	around := request.QueryStringParameters["around"]
+	if around != "" && lastPageKey == "" {
+		users, err := query.FetchByUsername(dynamoClient, ctx, USERTABLE, around)
+		if err != nil {
+			return nil, http.StatusNotFound, fmt.Errorf("failed to fetch data around user: %v", err)
+		}
+		if len(users) < 1 {
+			users, err = query.FetchByPreviousUsername(dynamoClient, ctx, USERNAMEHISTORYTABLE, USERTABLE, around)
+			if err != nil {
+				return nil, http.StatusNotFound, fmt.Errorf("failed to fetch data around user: %v", err)
+			}
+		}
+		if len(users) < 1 {
+			return nil, http.StatusNotFound, fmt.Errorf("failed to fetch data around user: user not found")
+		}
+		if users[0].Region == "" {
+			return nil, http.StatusNotFound, fmt.Errorf("failed to fetch data around user: user is not ranked in any region")
+		}
+		users, newPageKey, prevPageKey, err := query.FetchAround(dynamoClient, ctx, pageKeySigner, USERTABLE, int32(pageSize), &users[0])
+		if err != nil {
+			return nil, http.StatusNotFound, fmt.Errorf("failed to fetch data around user: %v", err)
+		}
+		if err := query.AssignRanks(dynamoClient, ctx, RANKCOUNTTABLE, []string{users[0].Region}, users); err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to calculate ranks: %v", err)
+		}
+		return &FetchResponse{
+			Message:     "successfully fetched data around user",
+			NewPageKey:  newPageKey,
+			PrevPageKey: prevPageKey,
+			Users:       users,
+		}, http.StatusOK, nil
+	}
+
+	// elo anchors the first page, following pages are continued with the regular page keys.
+	eloStr := request.QueryStringParameters["elo"]
+	if eloStr != "" && lastPageKey == "" {
+		elo, err := strconv.Atoi(eloStr)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid elo: expected integer")
+		}
+		users, newPageKey, prevPageKey, err := query.FetchByElo(dynamoClient, ctx, pageKeySigner, USERTABLE, int32(pageSize), region, elo)
+		if err != nil {
+			return nil, http.StatusNotFound, fmt.Errorf("failed to fetch data by elo: %v", err)
+		}
+		if err := query.AssignRanks(dynamoClient, ctx, RANKCOUNTTABLE, []string{region}, users); err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to calculate ranks: %v", err)
+		}
+		return &FetchResponse{
+			Message:     "successfully fetched data by elo",
+			NewPageKey:  newPageKey,
+			PrevPageKey: prevPageKey,
+			Users:       users,
+		}, http.StatusOK, nil
+	}
+
+	users, newPageKey, prevPageKey, err := query.FetchByPage(dynamoClient, ctx, pageKeySigner, USERTABLE, int32(pageSize), lastPageKey, region)
+	if errors.Is(err, query.ErrInvalidPageKey) {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to fetch data by page: %v", err)
+	} else if err != nil {
 		return nil, http.StatusNotFound, fmt.Errorf("failed to fetch data by page: %v", err)
 	}
+	if err := query.AssignRanks(dynamoClient, ctx, RANKCOUNTTABLE, []string{region}, users); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to calculate ranks: %v", err)
+	}
 
 	return &FetchResponse{
-		Message:    "successfully fetched data by page",
-		NewPageKey: newPageKey,
-		Users:      users,
+		Message:     "successfully fetched data by page",
+		NewPageKey:  newPageKey,
+		PrevPageKey: prevPageKey,
+		Users:       users,
 	}, http.StatusOK, nil
 }
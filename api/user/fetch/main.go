@@ -5,15 +5,30 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/megakuul/leaderboard/api/user/fetch/auth"
+	"github.com/megakuul/leaderboard/api/user/fetch/query"
 )
 
 var (
-	REGION    = os.Getenv("AWS_REGION")
-	USERTABLE = os.Getenv("USERTABLE")
+	REGION               = os.Getenv("AWS_REGION")
+	USERTABLE            = os.Getenv("USERTABLE")
+	USERNAMEHISTORYTABLE = os.Getenv("USERNAMEHISTORYTABLE")
+	FRIENDTABLE          = os.Getenv("FRIENDTABLE")
+	RANKCOUNTTABLE       = os.Getenv("RANKCOUNTTABLE")
+	USERPOOLID           = os.Getenv("USERPOOLID")
+	USERPOOLCLIENTID     = os.Getenv("USERPOOLCLIENTID")
+	REGIONS              = []string{REGION} // default AWS_REGION
+	CURSOR_SECRET        = os.Getenv("CURSOR_SECRET")
+	CURSOR_TTL_MINUTES   = 60 // default 60
+	AVATAR_BASE_URL      = os.Getenv("AVATAR_BASE_URL")
+	ICON_HOST_ALLOWLIST  = []string{} // default none
 )
 
 func main() {
@@ -29,6 +44,48 @@ func run() error {
 	}
 	dynamoClient := dynamodb.NewFromConfig(awsConfig)
 
-	lambda.Start(FetchHandler(dynamoClient))
+	// regions are specified as comma separated list (e.g. "eu-central-1,us-east-1").
+	if regions := os.Getenv("REGIONS"); regions != "" {
+		REGIONS = []string{}
+		for _, region := range strings.Split(regions, ",") {
+			if region = strings.TrimSpace(region); region != "" {
+				REGIONS = append(REGIONS, region)
+			}
+		}
+	}
+
+	if cursorTtlMinutes, err := strconv.Atoi(os.Getenv("CURSOR_TTL_MINUTES")); err == nil {
+		CURSOR_TTL_MINUTES = cursorTtlMinutes
+	}
+	if CURSOR_SECRET == "" {
+		return fmt.Errorf("no CURSOR_SECRET provided")
+	}
+	pageKeySigner := &query.PageKeySigner{
+		Secret: []byte(CURSOR_SECRET),
+		TTL:    time.Duration(CURSOR_TTL_MINUTES) * time.Minute,
+	}
+
+	// allowed icon hosts are specified as comma separated list (e.g. "gravatar.com,avatars.githubusercontent.com").
+	if allowlist := os.Getenv("ICON_HOST_ALLOWLIST"); allowlist != "" {
+		for _, host := range strings.Split(allowlist, ",") {
+			if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+				ICON_HOST_ALLOWLIST = append(ICON_HOST_ALLOWLIST, host)
+			}
+		}
+	}
+	iconPolicy := &query.IconPolicy{
+		AvatarBaseUrl: AVATAR_BASE_URL,
+		AllowedHosts:  ICON_HOST_ALLOWLIST,
+	}
+
+	if USERPOOLID == "" || USERPOOLCLIENTID == "" {
+		return fmt.Errorf("no USERPOOLID or USERPOOLCLIENTID provided")
+	}
+	tokenVerifier := &auth.TokenVerifier{
+		Issuer:   fmt.Sprintf("https://cognito-idp.%s.amazonaws.com/%s", REGION, USERPOOLID),
+		Audience: USERPOOLCLIENTID,
+	}
+
+	lambda.Start(FetchHandler(dynamoClient, pageKeySigner, iconPolicy, tokenVerifier))
 	return nil
 }
@@ -0,0 +1,43 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+const (
+	MAX_AROUND = 50
+)
+
+// FetchAround fetches the user together with up to count users above and below the user within the region of the user.
+// the returned page keys continue the page like pages fetched by FetchByPage.
+func FetchAround(dynamoClient *dynamodb.Client, ctx context.Context, signer *PageKeySigner, tableName string, count int32, user *UserOutput) ([]UserOutput, string, string, error) {
+	if count > MAX_AROUND {
+		count = MAX_AROUND
+	}
+	shape := fmt.Sprintf("page:%s", user.Region)
+
+	startKey, err := attributevalue.MarshalMap(regionKey(user))
+	if err != nil {
+		return nil, "", "", err
+	}
+	above, aboveLastEvaluatedKey, err := queryRegion(dynamoClient, ctx, tableName, user.Region, "", 0, startKey, true, count)
+	if err != nil {
+		return nil, "", "", err
+	}
+	below, belowLastEvaluatedKey, err := queryRegion(dynamoClient, ctx, tableName, user.Region, "", 0, startKey, false, count)
+	if err != nil {
+		return nil, "", "", err
+	}
+	reverseUsers(above)
+	users := append(append(above, *user), below...)
+
+	newPageKey, prevPageKey, err := serializeRegionPageKeys(signer, shape, users, len(aboveLastEvaluatedKey) > 0, len(belowLastEvaluatedKey) > 0)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return users, newPageKey, prevPageKey, nil
+}
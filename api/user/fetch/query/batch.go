@@ -0,0 +1,30 @@
+package query
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// batchGetItems fetches the items of the keys in chunks of MAX_BATCH_GET, keys without item are skipped.
+func batchGetItems(dynamoClient *dynamodb.Client, ctx context.Context, tableName string, keys []map[string]types.AttributeValue) ([]map[string]types.AttributeValue, error) {
+	items := []map[string]types.AttributeValue{}
+	for start := 0; start < len(keys); start += MAX_BATCH_GET {
+		end := min(start+MAX_BATCH_GET, len(keys))
+		requestItems := map[string]types.KeysAndAttributes{
+			tableName: {Keys: keys[start:end]},
+		}
+		for len(requestItems) > 0 {
+			output, err := dynamoClient.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+				RequestItems: requestItems,
+			})
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, output.Responses[tableName]...)
+			requestItems = output.UnprocessedKeys
+		}
+	}
+	return items, nil
+}
@@ -2,40 +2,33 @@ package query
 
 import (
 	"context"
+	"fmt"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
-func FetchByElo(dynamoClient *dynamodb.Client, ctx context.Context, tableName string, pageSize int32, region, elo string) ([]UserOutput, error) {
+// FetchByElo fetches one page of the users of the region starting on the elo (descending).
+// the returned page keys continue the page like pages fetched by FetchByPage.
+func FetchByElo(dynamoClient *dynamodb.Client, ctx context.Context, signer *PageKeySigner, tableName string, pageSize int32, region string, elo int) ([]UserOutput, string, string, error) {
 	if pageSize > MAX_PAGESIZE {
 		pageSize = MAX_PAGESIZE
 	}
+	shape := fmt.Sprintf("page:%s", region)
 
-	output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
-		TableName: aws.String(tableName),
-		IndexName: aws.String("region_gsi"),
-		ExpressionAttributeNames: map[string]string{
-			"#user_region": "user_region",
-			"#elo":         "elo",
-		},
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":user_region": &types.AttributeValueMemberS{Value: region},
-			":elo":         &types.AttributeValueMemberN{Value: elo},
-		},
-		KeyConditionExpression: aws.String("#user_region = :user_region AND #elo <= :elo"),
-		ScanIndexForward:       aws.Bool(false),
-		Limit:                  aws.Int32(pageSize),
-	})
+	users, lastEvaluatedKey, err := queryRegion(dynamoClient, ctx, tableName, region, "<=", elo, nil, false, pageSize)
 	if err != nil {
-		return nil, err
+		return nil, "", "", err
 	}
-	var users []UserOutput
-	err = attributevalue.UnmarshalListOfMaps(output.Items, &users)
+	hasPrev := false
+	if len(users) > 0 {
+		hasPrev, err = hasUsersBefore(dynamoClient, ctx, tableName, &users[0])
+		if err != nil {
+			return nil, "", "", err
+		}
+	}
+	newPageKey, prevPageKey, err := serializeRegionPageKeys(signer, shape, users, hasPrev, len(lastEvaluatedKey) > 0)
 	if err != nil {
-		return nil, err
+		return nil, "", "", err
 	}
-	return users, nil
+	return users, newPageKey, prevPageKey, nil
 }
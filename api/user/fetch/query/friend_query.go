@@ -0,0 +1,94 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// status of a mutual friendship in the friend table (requests are "outgoing" or "incoming").
+const FRIEND_STATUS_ACCEPTED = "accepted"
+
+// FetchFriends fetches the user of the subject together with its accepted friends sorted by elo (descending).
+// the rank is the position within the friends (ties share a rank), disabled friends are skipped.
+// private friends are included, as the friendship was accepted by both users.
+func FetchFriends(dynamoClient *dynamodb.Client, ctx context.Context, friendTableName, userTableName, subject string) ([]UserOutput, error) {
+	keys := []map[string]types.AttributeValue{{
+		"subject": &types.AttributeValueMemberS{Value: subject},
+	}}
+	var lastEvaluatedKey map[string]types.AttributeValue
+	for {
+		output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
+			TableName: aws.String(friendTableName),
+			ExpressionAttributeNames: map[string]string{
+				"#subject":        "subject",
+				"#friend_subject": "friend_subject",
+				"#status":         "status",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":subject": &types.AttributeValueMemberS{Value: subject},
+				":status":  &types.AttributeValueMemberS{Value: FRIEND_STATUS_ACCEPTED},
+			},
+			KeyConditionExpression: aws.String("#subject = :subject"),
+			FilterExpression:       aws.String("#status = :status"),
+			ProjectionExpression:   aws.String("#friend_subject"),
+			ExclusiveStartKey:      lastEvaluatedKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range output.Items {
+			if friendSubject, ok := item["friend_subject"]; ok {
+				keys = append(keys, map[string]types.AttributeValue{"subject": friendSubject})
+			}
+		}
+
+		lastEvaluatedKey = output.LastEvaluatedKey
+		if len(lastEvaluatedKey) < 1 {
+			break
+		}
+	}
+
+	items, err := batchGetItems(dynamoClient, ctx, userTableName, keys)
+	if err != nil {
+		return nil, err
+	}
+	var fetchedUsers []UserOutput
+	if err := attributevalue.UnmarshalListOfMaps(items, &fetchedUsers); err != nil {
+		return nil, err
+	}
+
+	users := []UserOutput{}
+	found := false
+	for _, user := range fetchedUsers {
+		if user.Subject == subject {
+			found = true
+		} else if user.Disabled || user.Username == "" {
+			continue
+		}
+		users = append(users, user)
+	}
+	if !found {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	sort.SliceStable(users, func(i, j int) bool {
+		if users[i].Elo != users[j].Elo {
+			return users[i].Elo > users[j].Elo
+		}
+		return users[i].Username < users[j].Username
+	})
+	for i := range users {
+		if i > 0 && users[i].Elo == users[i-1].Elo {
+			users[i].Rank = users[i-1].Rank
+		} else {
+			users[i].Rank = i + 1
+		}
+	}
+	return users, nil
+}
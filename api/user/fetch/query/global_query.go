@@ -0,0 +1,152 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// partitionCursor is the position of one region partition in the global page key.
+type partitionCursor struct {
+	Key  map[string]interface{} `json:"key,omitempty"`
+	Done bool                   `json:"done,omitempty"`
+}
+
+// partitionPage is one page of a region partition fetched for the merge.
+type partitionPage struct {
+	users            []UserOutput
+	lastEvaluatedKey map[string]types.AttributeValue
+	err              error
+}
+
+// FetchGlobalPage fetches one page of the users of all regions sorted by elo.
+// the region partitions of the region_gsi are queried concurrently and merged by elo,
+// the page key contains the position of every partition.
+func FetchGlobalPage(dynamoClient *dynamodb.Client, ctx context.Context, signer *PageKeySigner, tableName string, pageSize int32, lastPageKey string, regions []string) ([]UserOutput, string, error) {
+	if pageSize > MAX_PAGESIZE {
+		pageSize = MAX_PAGESIZE
+	}
+	shape := fmt.Sprintf("global:%s", strings.Join(regions, ","))
+
+	cursors := map[string]partitionCursor{}
+	if lastPageKey != "" {
+		if err := signer.verify(lastPageKey, shape, &cursors); err != nil {
+			return nil, "", fmt.Errorf("failed to deserialize lastPageKey: %w", err)
+		}
+	}
+
+	pages := make([]partitionPage, len(regions))
+	var wg sync.WaitGroup
+	for i, region := range regions {
+		cursor := cursors[region]
+		if cursor.Done {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, region string, cursor partitionCursor) {
+			defer wg.Done()
+			var pageKey map[string]types.AttributeValue
+			if cursor.Key != nil {
+				var err error
+				pageKey, err = attributevalue.MarshalMap(cursor.Key)
+				if err != nil {
+					pages[i].err = fmt.Errorf("failed to deserialize lastPageKey: %v", err)
+					return
+				}
+			}
+			output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
+				TableName: aws.String(tableName),
+				IndexName: aws.String("region_gsi"),
+				ExpressionAttributeNames: map[string]string{
+					"#user_region": "user_region",
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":user_region": &types.AttributeValueMemberS{Value: region},
+				},
+				KeyConditionExpression: aws.String("#user_region = :user_region"),
+				Limit:                  aws.Int32(pageSize),
+				ScanIndexForward:       aws.Bool(false),
+				ExclusiveStartKey:      pageKey,
+			})
+			if err != nil {
+				pages[i].err = err
+				return
+			}
+			if err := attributevalue.UnmarshalListOfMaps(output.Items, &pages[i].users); err != nil {
+				pages[i].err = err
+				return
+			}
+			pages[i].lastEvaluatedKey = output.LastEvaluatedKey
+		}(i, region, cursor)
+	}
+	wg.Wait()
+	for _, page := range pages {
+		if page.err != nil {
+			return nil, "", page.err
+		}
+	}
+
+	// k-way merge of the partitions. the merge stops if a partition that is not exhausted runs out of fetched users
+	// (e.g. if dynamodb returned a partial page), as its next users could have a higher elo than the remaining ones.
+	consumed := make([]int, len(regions))
+	users := []UserOutput{}
+	for len(users) < int(pageSize) {
+		next := -1
+		blocked := false
+		for i, region := range regions {
+			if cursors[region].Done {
+				continue
+			}
+			if consumed[i] >= len(pages[i].users) {
+				if len(pages[i].lastEvaluatedKey) > 0 {
+					blocked = true
+				}
+				continue
+			}
+			if next < 0 || pages[i].users[consumed[i]].Elo > pages[next].users[consumed[next]].Elo {
+				next = i
+			}
+		}
+		if blocked || next < 0 {
+			break
+		}
+		users = append(users, pages[next].users[consumed[next]])
+		consumed[next]++
+	}
+
+	newCursors := map[string]partitionCursor{}
+	allDone := true
+	for i, region := range regions {
+		cursor := cursors[region]
+		if !cursor.Done {
+			if consumed[i] >= len(pages[i].users) && len(pages[i].lastEvaluatedKey) < 1 {
+				cursor = partitionCursor{Done: true}
+			} else if consumed[i] > 0 {
+				last := pages[i].users[consumed[i]-1]
+				cursor = partitionCursor{Key: map[string]interface{}{
+					"subject":     last.Subject,
+					"user_region": last.Region,
+					"elo":         last.Elo,
+				}}
+			}
+		}
+		if !cursor.Done {
+			allDone = false
+		}
+		newCursors[region] = cursor
+	}
+	if allDone {
+		return users, "", nil
+	}
+	newPageKey, err := signer.sign(shape, &newCursors)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to serialize new pagekey: %v", err)
+	}
+	return users, newPageKey, nil
+}
@@ -0,0 +1,53 @@
+package query
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type usernameHistoryOutput struct {
+	Subject string `dynamodbav:"subject"`
+}
+
+// FetchByPreviousUsername resolves a previous username of a user via the username history.
+// if the username is unknown, no user is returned.
+func FetchByPreviousUsername(dynamoClient *dynamodb.Client, ctx context.Context, historyTableName, userTableName, username string) ([]UserOutput, error) {
+	historyOutput, err := dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(historyTableName),
+		Key: map[string]types.AttributeValue{
+			"username": &types.AttributeValueMemberS{Value: username},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if historyOutput.Item == nil {
+		return []UserOutput{}, nil
+	}
+	var history usernameHistoryOutput
+	if err := attributevalue.UnmarshalMap(historyOutput.Item, &history); err != nil {
+		return nil, err
+	}
+
+	userOutput, err := dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(userTableName),
+		Key: map[string]types.AttributeValue{
+			"subject": &types.AttributeValueMemberS{Value: history.Subject},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if userOutput.Item == nil {
+		return []UserOutput{}, nil
+	}
+	var user UserOutput
+	if err := attributevalue.UnmarshalMap(userOutput.Item, &user); err != nil {
+		return nil, err
+	}
+	return []UserOutput{user}, nil
+}
@@ -0,0 +1,43 @@
+package query
+
+import (
+	"net/url"
+	"strings"
+)
+
+// IconPolicy restricts the iconurls returned to visitors to managed avatars and allowed hosts.
+// the user update rejects other iconurls, iconurls that were set before are removed from the output.
+type IconPolicy struct {
+	AvatarBaseUrl string
+	AllowedHosts  []string
+}
+
+// Sanitize removes the iconurls of the users that do not match the policy.
+func (p *IconPolicy) Sanitize(users []UserOutput) {
+	for i := range users {
+		if !p.allowed(users[i].IconUrl) {
+			users[i].IconUrl = ""
+		}
+	}
+}
+
+func (p *IconPolicy) allowed(iconUrl string) bool {
+	if iconUrl == "" {
+		return true
+	}
+	parsedUrl, err := url.Parse(iconUrl)
+	if err != nil || parsedUrl.Scheme != "https" || parsedUrl.User != nil || parsedUrl.Host == "" {
+		return false
+	}
+	if p.AvatarBaseUrl != "" && strings.HasPrefix(iconUrl, p.AvatarBaseUrl) {
+		name := strings.TrimPrefix(iconUrl, p.AvatarBaseUrl)
+		return name != "" && !strings.ContainsAny(name, "/\\?#%")
+	}
+	host := strings.ToLower(parsedUrl.Hostname())
+	for _, allowedHost := range p.AllowedHosts {
+		if host == allowedHost {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package query
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidPageKey indicates that the page key was tampered with, has expired or was issued for another query.
+var ErrInvalidPageKey = errors.New("invalid page key")
+
+// PageKeySigner signs the page keys handed out to clients. page keys are opaque tokens that carry
+// the position of the query, an expiry and the shape of the query (query type and parameters).
+type PageKeySigner struct {
+	Secret []byte
+	TTL    time.Duration
+}
+
+type signedPageKey struct {
+	Shape     string          `json:"s"`
+	ExpiresAt int64           `json:"e"`
+	Payload   json.RawMessage `json:"p"`
+}
+
+// sign serializes the payload into a page key that is only valid for queries with the same shape.
+func (s *PageKeySigner) sign(shape string, payload interface{}) (string, error) {
+	encodedPayload, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	encodedKey, err := json.Marshal(&signedPageKey{
+		Shape:     shape,
+		ExpiresAt: time.Now().Add(s.TTL).Unix(),
+		Payload:   encodedPayload,
+	})
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write(encodedKey)
+	return fmt.Sprintf("%s.%s",
+		base64.RawURLEncoding.EncodeToString(encodedKey),
+		base64.RawURLEncoding.EncodeToString(mac.Sum(nil)),
+	), nil
+}
+
+// verify checks the signature, expiry and shape of the page key and deserializes its payload.
+// every invalid page key results in ErrInvalidPageKey.
+func (s *PageKeySigner) verify(pageKey, shape string, payload interface{}) error {
+	encodedKey, encodedSignature, ok := strings.Cut(pageKey, ".")
+	if !ok {
+		return fmt.Errorf("%w: malformed page key", ErrInvalidPageKey)
+	}
+	decodedKey, err := base64.RawURLEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return fmt.Errorf("%w: malformed page key", ErrInvalidPageKey)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return fmt.Errorf("%w: malformed page key", ErrInvalidPageKey)
+	}
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write(decodedKey)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return fmt.Errorf("%w: signature mismatch", ErrInvalidPageKey)
+	}
+
+	var key signedPageKey
+	if err := json.Unmarshal(decodedKey, &key); err != nil {
+		return fmt.Errorf("%w: malformed page key", ErrInvalidPageKey)
+	}
+	if key.ExpiresAt < time.Now().Unix() {
+		return fmt.Errorf("%w: page key has expired", ErrInvalidPageKey)
+	}
+	if key.Shape != shape {
+		return fmt.Errorf("%w: page key was issued for another query", ErrInvalidPageKey)
+	}
+	if err := json.Unmarshal(key.Payload, payload); err != nil {
+		return fmt.Errorf("%w: malformed page key", ErrInvalidPageKey)
+	}
+	return nil
+}
@@ -2,8 +2,6 @@ package query
 
 import (
 	"context"
-	"encoding/base64"
-	"encoding/json"
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -12,74 +10,156 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
-func FetchByPage(dynamoClient *dynamodb.Client, ctx context.Context, tableName string, pageSize int32, lastPageKey, region string) ([]UserOutput, string, error) {
+const (
+	PAGE_DIRECTION_NEXT = "next"
+	PAGE_DIRECTION_PREV = "prev"
+)
+
+// regionPageKey is the position in a region partition, it contains the key of the first (prev) or
+// last (next) user of the page and the direction the page key continues to.
+type regionPageKey struct {
+	Key       map[string]interface{} `json:"key"`
+	Direction string                 `json:"dir"`
+}
+
+// FetchByPage fetches one page of the users of the region sorted by elo (descending).
+// the page key continues either to the next (lower elo) or to the previous (higher elo) page,
+// the page keys in both directions are returned (empty if there are no more users in this direction).
+func FetchByPage(dynamoClient *dynamodb.Client, ctx context.Context, signer *PageKeySigner, tableName string, pageSize int32, lastPageKey, region string) ([]UserOutput, string, string, error) {
 	if pageSize > MAX_PAGESIZE {
 		pageSize = MAX_PAGESIZE
 	}
+	shape := fmt.Sprintf("page:%s", region)
 
 	var pageKey map[string]types.AttributeValue = nil
+	direction := PAGE_DIRECTION_NEXT
 	if lastPageKey != "" {
+		var key regionPageKey
+		if err := signer.verify(lastPageKey, shape, &key); err != nil {
+			return nil, "", "", fmt.Errorf("failed to deserialize lastPageKey: %w", err)
+		}
 		var err error
-		pageKey, err = deserializePageKey(lastPageKey)
+		pageKey, err = attributevalue.MarshalMap(key.Key)
 		if err != nil {
-			return nil, "", fmt.Errorf("failed to deserialize lastPageKey: %v", err)
+			return nil, "", "", fmt.Errorf("failed to deserialize lastPageKey: %v", err)
 		}
+		direction = key.Direction
 	}
 
-	output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
-		TableName: aws.String(tableName),
-		IndexName: aws.String("region_gsi"),
-		ExpressionAttributeNames: map[string]string{
-			"#user_region": "user_region",
-		},
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":user_region": &types.AttributeValueMemberS{Value: region},
-		},
-		KeyConditionExpression: aws.String("#user_region = :user_region"),
-		Limit:                  aws.Int32(pageSize),
-		ScanIndexForward:       aws.Bool(false),
-		ExclusiveStartKey:      pageKey,
-	})
+	if direction == PAGE_DIRECTION_PREV {
+		users, lastEvaluatedKey, err := queryRegion(dynamoClient, ctx, tableName, region, "", 0, pageKey, true, pageSize)
+		if err != nil {
+			return nil, "", "", err
+		}
+		reverseUsers(users)
+		newPageKey, prevPageKey, err := serializeRegionPageKeys(signer, shape, users, len(lastEvaluatedKey) > 0, true)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return users, newPageKey, prevPageKey, nil
+	}
 
+	users, lastEvaluatedKey, err := queryRegion(dynamoClient, ctx, tableName, region, "", 0, pageKey, false, pageSize)
 	if err != nil {
-		return nil, "", err
+		return nil, "", "", err
 	}
-	var users []UserOutput
-	err = attributevalue.UnmarshalListOfMaps(output.Items, &users)
+	newPageKey, prevPageKey, err := serializeRegionPageKeys(signer, shape, users, pageKey != nil, len(lastEvaluatedKey) > 0)
 	if err != nil {
-		return nil, "", err
+		return nil, "", "", err
 	}
-	if len(output.LastEvaluatedKey) < 1 {
-		return users, "", nil
+	return users, newPageKey, prevPageKey, nil
+}
+
+// queryRegion queries one page of the region partition of the region_gsi starting after the startKey.
+// if eloOperator is set (e.g. "<="), only users whose elo matches the operator compared to the elo are queried.
+func queryRegion(dynamoClient *dynamodb.Client, ctx context.Context, tableName, region, eloOperator string, elo int, startKey map[string]types.AttributeValue, ascending bool, limit int32) ([]UserOutput, map[string]types.AttributeValue, error) {
+	expressionAttributeNames := map[string]string{
+		"#user_region": "user_region",
 	}
-	newPageKey, err := serializePageKey(output.LastEvaluatedKey)
+	expressionAttributeValues := map[string]types.AttributeValue{
+		":user_region": &types.AttributeValueMemberS{Value: region},
+	}
+	keyConditionExpression := "#user_region = :user_region"
+	if eloOperator != "" {
+		// dynamodb rejects unused expression attributes, therefore they are only set if required.
+		expressionAttributeNames["#elo"] = "elo"
+		expressionAttributeValues[":elo"] = &types.AttributeValueMemberN{Value: fmt.Sprint(elo)}
+		keyConditionExpression += fmt.Sprintf(" AND #elo %s :elo", eloOperator)
+	}
+
+	output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(tableName),
+		IndexName:                 aws.String("region_gsi"),
+		ExpressionAttributeNames:  expressionAttributeNames,
+		ExpressionAttributeValues: expressionAttributeValues,
+		KeyConditionExpression:    aws.String(keyConditionExpression),
+		Limit:                     aws.Int32(limit),
+		ScanIndexForward:          aws.Bool(ascending),
+		ExclusiveStartKey:         startKey,
+	})
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to serialize new pagekey: %v", err)
+		return nil, nil, err
+	}
+	users := []UserOutput{}
+	if err := attributevalue.UnmarshalListOfMaps(output.Items, &users); err != nil {
+		return nil, nil, err
 	}
-	return users, newPageKey, nil
+	return users, output.LastEvaluatedKey, nil
 }
 
-func serializePageKey(pageKey map[string]types.AttributeValue) (string, error) {
-	var translatedMap map[string]interface{}
-	if err := attributevalue.UnmarshalMap(pageKey, &translatedMap); err != nil {
-		return "", err
+// hasUsersBefore checks if the region contains users sorted before the user (higher elo).
+func hasUsersBefore(dynamoClient *dynamodb.Client, ctx context.Context, tableName string, user *UserOutput) (bool, error) {
+	startKey, err := attributevalue.MarshalMap(regionKey(user))
+	if err != nil {
+		return false, err
 	}
-	encodedMap, err := json.Marshal(&translatedMap)
+	users, _, err := queryRegion(dynamoClient, ctx, tableName, user.Region, "", 0, startKey, true, 1)
 	if err != nil {
-		return "", err
+		return false, err
 	}
-	return base64.RawURLEncoding.EncodeToString(encodedMap), nil
+	return len(users) > 0, nil
 }
 
-func deserializePageKey(pageKey string) (map[string]types.AttributeValue, error) {
-	decodedPageKey, err := base64.RawURLEncoding.DecodeString(pageKey)
-	if err != nil {
-		return nil, err
+// regionKey returns the region_gsi key of the user.
+func regionKey(user *UserOutput) map[string]interface{} {
+	return map[string]interface{}{
+		"subject":     user.Subject,
+		"user_region": user.Region,
+		"elo":         user.Elo,
 	}
-	var decodedMap map[string]interface{}
-	err = json.Unmarshal(decodedPageKey, &decodedMap)
-	if err != nil {
-		return nil, err
+}
+
+// serializeRegionPageKeys creates the page keys of the next and previous page of the users.
+func serializeRegionPageKeys(signer *PageKeySigner, shape string, users []UserOutput, hasPrev, hasNext bool) (string, string, error) {
+	if len(users) < 1 {
+		return "", "", nil
+	}
+	newPageKey, prevPageKey := "", ""
+	if hasNext {
+		var err error
+		newPageKey, err = signer.sign(shape, &regionPageKey{
+			Key:       regionKey(&users[len(users)-1]),
+			Direction: PAGE_DIRECTION_NEXT,
+		})
+		if err != nil {
+			return "", "", fmt.Errorf("failed to serialize new pagekey: %v", err)
+		}
+	}
+	if hasPrev {
+		var err error
+		prevPageKey, err = signer.sign(shape, &regionPageKey{
+			Key:       regionKey(&users[0]),
+			Direction: PAGE_DIRECTION_PREV,
+		})
+		if err != nil {
+			return "", "", fmt.Errorf("failed to serialize prev pagekey: %v", err)
+		}
+	}
+	return newPageKey, prevPageKey, nil
+}
+
+func reverseUsers(users []UserOutput) {
+	for i, j := 0, len(users)-1; i < j; i, j = i+1, j-1 {
+		users[i], users[j] = users[j], users[i]
 	}
-	return attributevalue.MarshalMap(decodedMap)
 }
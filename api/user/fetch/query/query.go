@@ -4,14 +4,18 @@
 package query
 
 const (
-	MAX_PAGESIZE = 100
+	MAX_PAGESIZE  = 100
+	MAX_BATCH_GET = 100
 )
 
 type UserOutput struct {
+	Subject  string `dynamodbav:"subject" json:"-"`
 	Username string `dynamodbav:"username" json:"username"`
 	Disabled bool   `dynamodbav:"disabled" json:"disabled"`
+	Private  bool   `dynamodbav:"private" json:"private"`
 	Region   string `dynamodbav:"user_region" json:"region"`
 	Title    string `dynamodbav:"title" json:"title"`
 	IconUrl  string `dynamodbav:"iconurl" json:"iconurl"`
 	Elo      int    `dynamodbav:"elo" json:"elo"`
+	Rank     int    `dynamodbav:"-" json:"rank"`
 }
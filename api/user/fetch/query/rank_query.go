@@ -0,0 +1,122 @@
+package query
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// rankCounter contains the number of users of a region with the same elo.
+type rankCounter struct {
+	Count int `dynamodbav:"count"`
+}
+
+// countByElo counts the users of the region whose elo matches the operator (e.g. ">") compared to the elo.
+// the count is summed from the rank counters (one item per elo) instead of reading every user of the range.
+func countByElo(dynamoClient *dynamodb.Client, ctx context.Context, tableName, region, operator string, elo int) (int, error) {
+	count := 0
+	var lastEvaluatedKey map[string]types.AttributeValue
+	for {
+		output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
+			TableName: aws.String(tableName),
+			ExpressionAttributeNames: map[string]string{
+				"#user_region": "user_region",
+				"#elo":         "elo",
+				"#count":       "count",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":user_region": &types.AttributeValueMemberS{Value: region},
+				":elo":         &types.AttributeValueMemberN{Value: strconv.Itoa(elo)},
+			},
+			KeyConditionExpression: aws.String("#user_region = :user_region AND #elo " + operator + " :elo"),
+			ProjectionExpression:   aws.String("#count"),
+			ExclusiveStartKey:      lastEvaluatedKey,
+		})
+		if err != nil {
+			return 0, err
+		}
+		var counters []rankCounter
+		if err := attributevalue.UnmarshalListOfMaps(output.Items, &counters); err != nil {
+			return 0, err
+		}
+		for _, counter := range counters {
+			count += counter.Count
+		}
+
+		lastEvaluatedKey = output.LastEvaluatedKey
+		if len(lastEvaluatedKey) < 1 {
+			break
+		}
+	}
+	return count, nil
+}
+
+// countByEloInRegions sums the counts of countByElo over all regions.
+func countByEloInRegions(dynamoClient *dynamodb.Client, ctx context.Context, tableName string, regions []string, operator string, elo int) (int, error) {
+	count := 0
+	for _, region := range regions {
+		regionCount, err := countByElo(dynamoClient, ctx, tableName, region, operator, elo)
+		if err != nil {
+			return 0, err
+		}
+		count += regionCount
+	}
+	return count, nil
+}
+
+// AssignRanks sets the rank (1-based, ties share a rank) of the users within the regions.
+// the users must be a contiguous range of the regions sorted by elo (descending), e.g. a page of the region_gsi.
+// the rank is derived from the number of users with a higher elo, so only users above the page are counted.
+func AssignRanks(dynamoClient *dynamodb.Client, ctx context.Context, tableName string, regions []string, users []UserOutput) error {
+	if len(users) < 1 {
+		return nil
+	}
+	topElo := users[0].Elo
+	above, err := countByEloInRegions(dynamoClient, ctx, tableName, regions, ">", topElo)
+	if err != nil {
+		return err
+	}
+
+	topCount := 0
+	for topCount < len(users) && users[topCount].Elo == topElo {
+		users[topCount].Rank = above + 1
+		topCount++
+	}
+	if topCount == len(users) {
+		return nil
+	}
+
+	// users with the top elo may also be located before the page, therefore they are counted.
+	atLeastTop, err := countByEloInRegions(dynamoClient, ctx, tableName, regions, ">=", topElo)
+	if err != nil {
+		return err
+	}
+	for i := topCount; i < len(users); i++ {
+		if users[i].Elo == users[i-1].Elo {
+			users[i].Rank = users[i-1].Rank
+		} else {
+			users[i].Rank = atLeastTop + (i - topCount) + 1
+		}
+	}
+	return nil
+}
+
+// AssignRank sets the rank (1-based, ties share a rank) of every user within the region of the user.
+func AssignRank(dynamoClient *dynamodb.Client, ctx context.Context, tableName string, users []UserOutput) error {
+	for i := range users {
+		// users that never completed an update are not indexed in a region.
+		if users[i].Region == "" {
+			continue
+		}
+		above, err := countByElo(dynamoClient, ctx, tableName, users[i].Region, ">", users[i].Elo)
+		if err != nil {
+			return err
+		}
+		users[i].Rank = above + 1
+	}
+	return nil
+}
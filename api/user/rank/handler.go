@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/megakuul/leaderboard/api/user/rank/record"
+	"github.com/megakuul/leaderboard/api/user/rank/update"
+)
+
+func RankHandler(dynamoClient *dynamodb.Client) func(context.Context, events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+	return func(ctx context.Context, event events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+		response := events.DynamoDBEventResponse{
+			BatchItemFailures: []events.DynamoDBBatchItemFailure{},
+		}
+		for i, streamRecord := range event.Records {
+			if err := runRankHandler(dynamoClient, &streamRecord, ctx); err != nil {
+				log.Printf("ERROR RECORD %s: %v\n", streamRecord.EventID, err)
+				// records of the same user must be applied in order, therefore the remaining records are retried as well.
+				for _, failedRecord := range event.Records[i:] {
+					response.BatchItemFailures = append(response.BatchItemFailures, events.DynamoDBBatchItemFailure{
+						ItemIdentifier: failedRecord.Change.SequenceNumber,
+					})
+				}
+				break
+			}
+		}
+		return response, nil
+	}
+}
+
+func runRankHandler(dynamoClient *dynamodb.Client, streamRecord *events.DynamoDBEventRecord, ctx context.Context) error {
+	previous, err := parseBucket(streamRecord.Change.OldImage)
+	if err != nil {
+		return fmt.Errorf("failed to deserialize old image: %v", err)
+	}
+	next, err := parseBucket(streamRecord.Change.NewImage)
+	if err != nil {
+		return fmt.Errorf("failed to deserialize new image: %v", err)
+	}
+	// most updates (e.g. username or icon changes) do not move the user.
+	if (previous == nil && next == nil) || (previous != nil && next != nil && *previous == *next) {
+		return nil
+	}
+
+	err = update.ApplyRankDelta(dynamoClient, ctx, RANKCOUNTTABLE, IDEMPOTENCYTABLE, streamRecord.EventID, previous, next, IDEMPOTENCY_TTL_HOURS)
+	if errors.Is(err, update.ErrEventApplied) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to update rank counters: %v", err)
+	}
+	return nil
+}
+
+// parseBucket returns the rank counter of the user image, nil if the user is not listed (or the image is empty).
+func parseBucket(image map[string]events.DynamoDBAttributeValue) (*update.Bucket, error) {
+	if len(image) < 1 {
+		return nil, nil
+	}
+	var user record.UserImage
+	if err := record.UnmarshalImage(image, &user); err != nil {
+		return nil, err
+	}
+	if user.Region == "" {
+		return nil, nil
+	}
+	return &update.Bucket{Region: user.Region, Elo: user.Elo}, nil
+}
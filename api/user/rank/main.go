@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+var (
+	REGION                = os.Getenv("AWS_REGION")
+	RANKCOUNTTABLE        = os.Getenv("RANKCOUNTTABLE")
+	IDEMPOTENCYTABLE      = os.Getenv("IDEMPOTENCYTABLE")
+	IDEMPOTENCY_TTL_HOURS = 48 // default 48
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatalf("ERROR INITIALIZATION: %v\n", err)
+	}
+}
+
+func run() error {
+	awsConfig, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(REGION))
+	if err != nil {
+		return fmt.Errorf("failed to load aws config: %v", err)
+	}
+	dynamoClient := dynamodb.NewFromConfig(awsConfig)
+
+	if idempotencyTtlHours, err := strconv.Atoi(os.Getenv("IDEMPOTENCY_TTL_HOURS")); err == nil {
+		IDEMPOTENCY_TTL_HOURS = idempotencyTtlHours
+	}
+
+	lambda.Start(RankHandler(dynamoClient))
+	return nil
+}
@@ -0,0 +1,9 @@
+package record
+
+// UserImage contains the attributes of a user that affect the ranks.
+// users without region are not listed in the leaderboard.
+type UserImage struct {
+	Subject string `dynamodbav:"subject"`
+	Region  string `dynamodbav:"user_region"`
+	Elo     int    `dynamodbav:"elo"`
+}
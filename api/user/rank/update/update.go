@@ -0,0 +1,80 @@
+// contains wrappers for database update functions.
+// main purpose is to abstract some boilerplate code
+// away from the handler.
+package update
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrEventApplied indicates that the stream event was already applied (e.g. by a previous invocation).
+var ErrEventApplied = errors.New("event was already applied")
+
+// Bucket identifies the counter of the users with the elo in the region.
+type Bucket struct {
+	Region string
+	Elo    int
+}
+
+// ApplyRankDelta moves a user from the previous bucket to the new bucket (nil if the user is not listed).
+// the counters are updated together with an idempotency marker of the event in one transaction,
+// if the event was already applied, ErrEventApplied is returned and no counter is updated.
+func ApplyRankDelta(dynamoClient *dynamodb.Client, ctx context.Context, tableName, idempotencyTableName, eventId string, previous, next *Bucket, ttlHours int) error {
+	expiresIn := time.Now().Add(time.Duration(ttlHours) * time.Hour).Unix()
+	transactItems := []types.TransactWriteItem{{
+		Put: &types.Put{
+			TableName: aws.String(idempotencyTableName),
+			Item: map[string]types.AttributeValue{
+				"idempotency_key": &types.AttributeValueMemberS{Value: fmt.Sprintf("rank#%s", eventId)},
+				"expires_in":      &types.AttributeValueMemberN{Value: strconv.Itoa(int(expiresIn))},
+			},
+			ConditionExpression: aws.String("attribute_not_exists(idempotency_key)"),
+		},
+	}}
+	if previous != nil {
+		transactItems = append(transactItems, newCountUpdate(tableName, previous, -1))
+	}
+	if next != nil {
+		transactItems = append(transactItems, newCountUpdate(tableName, next, 1))
+	}
+
+	_, err := dynamoClient.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		var cancelErr *types.TransactionCanceledException
+		if errors.As(err, &cancelErr) && len(cancelErr.CancellationReasons) > 0 &&
+			aws.ToString(cancelErr.CancellationReasons[0].Code) == "ConditionalCheckFailed" {
+			return ErrEventApplied
+		}
+		return err
+	}
+	return nil
+}
+
+func newCountUpdate(tableName string, bucket *Bucket, delta int) types.TransactWriteItem {
+	return types.TransactWriteItem{
+		Update: &types.Update{
+			TableName: aws.String(tableName),
+			Key: map[string]types.AttributeValue{
+				"user_region": &types.AttributeValueMemberS{Value: bucket.Region},
+				"elo":         &types.AttributeValueMemberN{Value: strconv.Itoa(bucket.Elo)},
+			},
+			ExpressionAttributeNames: map[string]string{
+				"#count": "count",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":delta": &types.AttributeValueMemberN{Value: strconv.Itoa(delta)},
+			},
+			UpdateExpression: aws.String("ADD #count :delta"),
+		},
+	}
+}
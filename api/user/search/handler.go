@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/megakuul/leaderboard/api/user/search/query"
+	"github.com/megakuul/leaderboard/api/user/search/search"
+)
+
+const (
+	MAX_QUERY_LENGTH = 32
+)
+
+type SearchResponse struct {
+	Message string             `json:"message"`
+	Users   []query.UserOutput `json:"users"`
+}
+
+func SearchHandler(dynamoClient *dynamodb.Client, iconPolicy *query.IconPolicy) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		response, code, err := runSearchHandler(dynamoClient, &request, ctx)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: code,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       err.Error(),
+			}, nil
+		}
+		iconPolicy.Sanitize(response.Users)
+		serializedResponse, err := json.Marshal(&response)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: http.StatusInternalServerError,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       "failed to serialize response",
+			}, nil
+		}
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode: code,
+			Headers:    map[string]string{"Content-Type": "application/json"},
+			Body:       string(serializedResponse),
+		}, nil
+	}
+}
+
+func runSearchHandler(dynamoClient *dynamodb.Client, request *events.APIGatewayV2HTTPRequest, ctx context.Context) (*SearchResponse, int, error) {
+	q := search.NormalizeQuery(request.QueryStringParameters["q"])
+	if q == "" {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to search: no query provided")
+	}
+	if len([]rune(q)) > MAX_QUERY_LENGTH {
+		return nil, http.StatusBadRequest, fmt.Errorf("maximum query length is %d", MAX_QUERY_LENGTH)
+	}
+
+	limit := DEFAULT_RESULTS
+	if limitStr := request.QueryStringParameters["limit"]; limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err != nil || parsedLimit < 1 {
+			return nil, http.StatusBadRequest, fmt.Errorf("failed to parse limit: expected positive number")
+		}
+		if parsedLimit > MAX_RESULTS {
+			return nil, http.StatusBadRequest, fmt.Errorf("failed to parse limit: maximum is %d", MAX_RESULTS)
+		}
+		limit = parsedLimit
+	}
+
+	// the search_gsi is partitioned by the first character of the search key,
+	// typos in the first character are therefore not tolerated.
+	prefix := string([]rune(q)[0])
+	candidates, err := query.FetchBySearchPrefix(dynamoClient, ctx, USERTABLE, prefix, q, MAX_CANDIDATES)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to search users: %v", err)
+	}
+	users := search.Rank(q, candidates, limit)
+
+	// the partition is only read for typo-tolerant matches if the prefix matches do not fill the result.
+	// each read is narrowed to a prefix of the query, so that the candidates are not just the start of the partition.
+	if search.MaxDistance(q) > 0 {
+		for _, keyPrefix := range search.FuzzyPrefixes(q) {
+			if len(users) >= limit {
+				break
+			}
+			fuzzyCandidates, err := query.FetchBySearchPrefix(dynamoClient, ctx, USERTABLE, prefix, keyPrefix, MAX_CANDIDATES)
+			if err != nil {
+				return nil, http.StatusInternalServerError, fmt.Errorf("failed to search users: %v", err)
+			}
+			candidates = append(candidates, fuzzyCandidates...)
+			users = search.Rank(q, candidates, limit)
+		}
+	}
+
+	return &SearchResponse{
+		Message: "successfully searched users",
+		Users:   users,
+	}, http.StatusOK, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/megakuul/leaderboard/api/user/search/query"
+)
+
+var (
+	REGION              = os.Getenv("AWS_REGION")
+	USERTABLE           = os.Getenv("USERTABLE")
+	DEFAULT_RESULTS     = 10  // default 10
+	MAX_RESULTS         = 25  // default 25
+	MAX_CANDIDATES      = 500 // default 500
+	AVATAR_BASE_URL     = os.Getenv("AVATAR_BASE_URL")
+	ICON_HOST_ALLOWLIST = []string{} // default none
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatalf("ERROR INITIALIZATION: %v\n", err)
+	}
+}
+
+func run() error {
+	awsConfig, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(REGION))
+	if err != nil {
+		return fmt.Errorf("failed to load aws config: %v", err)
+	}
+	dynamoClient := dynamodb.NewFromConfig(awsConfig)
+
+	if defaultResults, err := strconv.Atoi(os.Getenv("DEFAULT_RESULTS")); err == nil {
+		DEFAULT_RESULTS = defaultResults
+	}
+	if maxResults, err := strconv.Atoi(os.Getenv("MAX_RESULTS")); err == nil {
+		MAX_RESULTS = maxResults
+	}
+	if maxCandidates, err := strconv.Atoi(os.Getenv("MAX_CANDIDATES")); err == nil {
+		MAX_CANDIDATES = maxCandidates
+	}
+
+	// allowed icon hosts are specified as comma separated list (e.g. "gravatar.com,avatars.githubusercontent.com").
+	if allowlist := os.Getenv("ICON_HOST_ALLOWLIST"); allowlist != "" {
+		for _, host := range strings.Split(allowlist, ",") {
+			if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+				ICON_HOST_ALLOWLIST = append(ICON_HOST_ALLOWLIST, host)
+			}
+		}
+	}
+	iconPolicy := &query.IconPolicy{
+		AvatarBaseUrl: AVATAR_BASE_URL,
+		AllowedHosts:  ICON_HOST_ALLOWLIST,
+	}
+
+	lambda.Start(SearchHandler(dynamoClient, iconPolicy))
+	return nil
+}
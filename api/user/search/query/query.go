@@ -0,0 +1,12 @@
+// contains wrappers for database query functions.
+// main purpose is to abstract some boilerplate code
+// away from the handler.
+package query
+
+type UserOutput struct {
+	Username  string `dynamodbav:"username" json:"username"`
+	SearchKey string `dynamodbav:"search_key" json:"-"`
+	Disabled  bool   `dynamodbav:"disabled" json:"-"`
+	IconUrl   string `dynamodbav:"iconurl" json:"iconurl"`
+	Elo       int    `dynamodbav:"elo" json:"elo"`
+}
@@ -0,0 +1,61 @@
+package query
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// FetchBySearchPrefix fetches up to limit users whose search key starts with the query, sorted by search key.
+// if query is empty, the partition is read from the start (used as candidates for the fuzzy search).
+func FetchBySearchPrefix(dynamoClient *dynamodb.Client, ctx context.Context, tableName, prefix, query string, limit int) ([]UserOutput, error) {
+	expressionAttributeNames := map[string]string{
+		"#search_prefix": "search_prefix",
+		"#username":      "username",
+		"#search_key":    "search_key",
+		"#disabled":      "disabled",
+		"#iconurl":       "iconurl",
+		"#elo":           "elo",
+	}
+	expressionAttributeValues := map[string]types.AttributeValue{
+		":search_prefix": &types.AttributeValueMemberS{Value: prefix},
+	}
+	keyConditionExpression := "#search_prefix = :search_prefix"
+	if query != "" {
+		// dynamodb rejects unused expression attributes, therefore the key is only set if required.
+		expressionAttributeValues[":search_key"] = &types.AttributeValueMemberS{Value: query}
+		keyConditionExpression += " AND begins_with(#search_key, :search_key)"
+	}
+
+	users := []UserOutput{}
+	var lastEvaluatedKey map[string]types.AttributeValue
+	for len(users) < limit {
+		output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
+			TableName:                 aws.String(tableName),
+			IndexName:                 aws.String("search_gsi"),
+			ExpressionAttributeNames:  expressionAttributeNames,
+			ExpressionAttributeValues: expressionAttributeValues,
+			KeyConditionExpression:    aws.String(keyConditionExpression),
+			ProjectionExpression:      aws.String("#username, #search_key, #disabled, #iconurl, #elo"),
+			Limit:                     aws.Int32(int32(limit - len(users))),
+			ExclusiveStartKey:         lastEvaluatedKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		var page []UserOutput
+		if err := attributevalue.UnmarshalListOfMaps(output.Items, &page); err != nil {
+			return nil, err
+		}
+		users = append(users, page...)
+
+		lastEvaluatedKey = output.LastEvaluatedKey
+		if len(lastEvaluatedKey) < 1 {
+			break
+		}
+	}
+	return users, nil
+}
@@ -0,0 +1,102 @@
+// contains the matching of the username search.
+// usernames are matched case-insensitive by prefix, typos are tolerated with the prefix edit distance.
+package search
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/megakuul/leaderboard/api/user/search/query"
+)
+
+// NormalizeQuery normalizes the query like the search key of the users.
+// the query is lowercased and whitespace is collapsed to single spaces.
+func NormalizeQuery(q string) string {
+	return strings.Join(strings.Fields(strings.ToLower(q)), " ")
+}
+
+// MaxDistance returns the number of typos tolerated for the query, short queries must match more exactly.
+func MaxDistance(q string) int {
+	switch length := len([]rune(q)); {
+	case length < 3:
+		return 0
+	case length < 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// FuzzyPrefixes returns the search key prefixes that are read (in order) for typo-tolerant matches of the query.
+// most typos occur after the second character, so the partition is narrowed to the first two characters,
+// followed by keys that miss (or swap) the second character. the last fallback reads the partition without narrowing.
+func FuzzyPrefixes(q string) []string {
+	runes := []rune(q)
+	if len(runes) < 3 {
+		return []string{""}
+	}
+	return []string{string(runes[:2]), string([]rune{runes[0], runes[2]}), ""}
+}
+
+// PrefixDistance calculates the minimal edit distance between the query and any prefix of the key.
+func PrefixDistance(q, key string) int {
+	qRunes, keyRunes := []rune(q), []rune(key)
+	// previous[j] holds the distance between the processed query and the first j runes of the key.
+	previous := make([]int, len(keyRunes)+1)
+	current := make([]int, len(keyRunes)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(qRunes); i++ {
+		current[0] = i
+		for j := 1; j <= len(keyRunes); j++ {
+			cost := 1
+			if qRunes[i-1] == keyRunes[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	distance := previous[0]
+	for _, d := range previous {
+		distance = min(distance, d)
+	}
+	return distance
+}
+
+type match struct {
+	user     query.UserOutput
+	distance int
+}
+
+// Rank filters the candidates by the prefix distance to the query and returns up to limit users.
+// users are sorted by distance (exact prefix matches first) and by elo. disabled users are excluded.
+func Rank(q string, candidates []query.UserOutput, limit int) []query.UserOutput {
+	maxDistance := MaxDistance(q)
+	seen := map[string]bool{}
+	matches := []match{}
+	for _, candidate := range candidates {
+		if candidate.Disabled || seen[candidate.Username] {
+			continue
+		}
+		seen[candidate.Username] = true
+		distance := PrefixDistance(q, candidate.SearchKey)
+		if distance > maxDistance {
+			continue
+		}
+		matches = append(matches, match{user: candidate, distance: distance})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].user.Elo > matches[j].user.Elo
+	})
+
+	users := []query.UserOutput{}
+	for i := 0; i < len(matches) && i < limit; i++ {
+		users = append(users, matches[i].user)
+	}
+	return users
+}
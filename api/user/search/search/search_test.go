@@ -0,0 +1,172 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/megakuul/leaderboard/api/user/search/query"
+)
+
+func TestNormalizeQuery(t *testing.T) {
+	tests := []struct {
+		q    string
+		want string
+	}{
+		{"Alice", "alice"},
+		{"  Mega   Kuul ", "mega kuul"},
+		{"\tBÖB\n", "böb"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeQuery(tt.q); got != tt.want {
+			t.Errorf("NormalizeQuery(%q) = %q, want %q", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestMaxDistance(t *testing.T) {
+	tests := []struct {
+		q    string
+		want int
+	}{
+		{"", 0},
+		{"ab", 0},
+		{"abc", 1},
+		{"abcde", 1},
+		{"abcdef", 2},
+		// runes are counted, not bytes.
+		{"äöü", 1},
+	}
+	for _, tt := range tests {
+		if got := MaxDistance(tt.q); got != tt.want {
+			t.Errorf("MaxDistance(%q) = %d, want %d", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestFuzzyPrefixes(t *testing.T) {
+	tests := []struct {
+		q    string
+		want []string
+	}{
+		{"", []string{""}},
+		{"al", []string{""}},
+		{"ali", []string{"al", "ai", ""}},
+		{"äöü", []string{"äö", "äü", ""}},
+	}
+	for _, tt := range tests {
+		if got := FuzzyPrefixes(tt.q); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FuzzyPrefixes(%q) = %q, want %q", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestPrefixDistance(t *testing.T) {
+	tests := []struct {
+		q    string
+		key  string
+		want int
+	}{
+		{"ali", "alice", 0},
+		{"alice", "alice", 0},
+		{"", "alice", 0},
+		{"alx", "alice", 1},
+		{"aice", "alice", 1},
+		{"allice", "alice", 1},
+		{"alcie", "alice", 2},
+		{"alice", "ali", 2},
+		{"alice", "", 5},
+		{"bob", "alice", 3},
+		{"jürg", "jürgen", 0},
+		{"jurg", "jürgen", 1},
+	}
+	for _, tt := range tests {
+		if got := PrefixDistance(tt.q, tt.key); got != tt.want {
+			t.Errorf("PrefixDistance(%q, %q) = %d, want %d", tt.q, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestRank(t *testing.T) {
+	tests := []struct {
+		name       string
+		q          string
+		candidates []query.UserOutput
+		limit      int
+		want       []string
+	}{
+		{
+			name: "exact prefixes before typos, then by elo",
+			q:    "alic",
+			candidates: []query.UserOutput{
+				{Username: "Alec", SearchKey: "alec", Elo: 2000},
+				{Username: "Alx", SearchKey: "alx", Elo: 2500},
+				{Username: "Alica", SearchKey: "alica", Elo: 1500},
+				{Username: "Alice", SearchKey: "alice", Elo: 1000},
+				{Username: "Alicia", SearchKey: "alicia", Elo: 1200},
+				{Username: "Bob", SearchKey: "bob", Elo: 3000},
+			},
+			limit: 10,
+			want:  []string{"Alica", "Alicia", "Alice", "Alec"},
+		},
+		{
+			name: "equal elo keeps the candidate order",
+			q:    "ali",
+			candidates: []query.UserOutput{
+				{Username: "Alina", SearchKey: "alina", Elo: 1000},
+				{Username: "Alice", SearchKey: "alice", Elo: 1000},
+			},
+			limit: 10,
+			want:  []string{"Alina", "Alice"},
+		},
+		{
+			name: "short queries tolerate no typos",
+			q:    "al",
+			candidates: []query.UserOutput{
+				{Username: "Alice", SearchKey: "alice"},
+				{Username: "Ax", SearchKey: "ax"},
+			},
+			limit: 10,
+			want:  []string{"Alice"},
+		},
+		{
+			name: "skips disabled and duplicate users",
+			q:    "ali",
+			candidates: []query.UserOutput{
+				{Username: "Alina", SearchKey: "alina", Disabled: true},
+				{Username: "Alice", SearchKey: "alice", Elo: 1000},
+				{Username: "Alice", SearchKey: "alice", Elo: 1000},
+			},
+			limit: 10,
+			want:  []string{"Alice"},
+		},
+		{
+			name: "limits the results",
+			q:    "ali",
+			candidates: []query.UserOutput{
+				{Username: "Alice", SearchKey: "alice", Elo: 1000},
+				{Username: "Alina", SearchKey: "alina", Elo: 2000},
+			},
+			limit: 1,
+			want:  []string{"Alina"},
+		},
+		{
+			name:       "no candidates",
+			q:          "ali",
+			candidates: nil,
+			limit:      10,
+			want:       []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, user := range Rank(tt.q, tt.candidates, tt.limit) {
+				got = append(got, user.Username)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Rank() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/megakuul/leaderboard/api/user/stats/query"
+	"github.com/megakuul/leaderboard/api/user/stats/stats"
+)
+
+type StatsResponse struct {
+	Message string             `json:"message"`
+	Stats   *stats.StatsOutput `json:"stats"`
+}
+
+func StatsHandler(dynamoClient *dynamodb.Client) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		response, code, err := runStatsHandler(dynamoClient, &request, ctx)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: code,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       err.Error(),
+			}, nil
+		}
+		serializedResponse, err := json.Marshal(&response)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: http.StatusInternalServerError,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       "failed to serialize response",
+			}, nil
+		}
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode: code,
+			Headers:    map[string]string{"Content-Type": "application/json"},
+			Body:       string(serializedResponse),
+		}, nil
+	}
+}
+
+func runStatsHandler(dynamoClient *dynamodb.Client, request *events.APIGatewayV2HTTPRequest, ctx context.Context) (*StatsResponse, int, error) {
+	username := request.QueryStringParameters["username"]
+	if username == "" {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to fetch stats: no username provided")
+	}
+
+	user, err := query.FetchByUsername(dynamoClient, ctx, USERTABLE, username)
+	if err != nil {
+		return nil, http.StatusNotFound, fmt.Errorf("failed to fetch user: %v", err)
+	}
+
+	games, truncated, err := query.FetchGamesBySubject(dynamoClient, ctx, PARTICIPATIONTABLE, GAMETABLE, user.Subject, MAX_HISTORY_GAMES)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch games: %v", err)
+	}
+
+	userStats := stats.CalculateStats(user, games, MAXIMUM_TEAMMATES)
+	userStats.Truncated = truncated
+	return &StatsResponse{
+		Message: "successfully calculated stats",
+		Stats:   userStats,
+	}, http.StatusOK, nil
+}
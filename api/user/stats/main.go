@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+var (
+	REGION             = os.Getenv("AWS_REGION")
+	USERTABLE          = os.Getenv("USERTABLE")
+	GAMETABLE          = os.Getenv("GAMETABLE")
+	PARTICIPATIONTABLE = os.Getenv("PARTICIPATIONTABLE")
+	MAXIMUM_TEAMMATES  = 3   // default 3
+	MAX_HISTORY_GAMES  = 500 // default 500
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatalf("ERROR INITIALIZATION: %v\n", err)
+	}
+}
+
+func run() error {
+	awsConfig, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(REGION))
+	if err != nil {
+		return fmt.Errorf("failed to load aws config: %v", err)
+	}
+	dynamoClient := dynamodb.NewFromConfig(awsConfig)
+
+	if maximumTeammates, err := strconv.Atoi(os.Getenv("MAXIMUM_TEAMMATES")); err == nil {
+		MAXIMUM_TEAMMATES = maximumTeammates
+	}
+	if maxHistoryGames, err := strconv.Atoi(os.Getenv("MAX_HISTORY_GAMES")); err == nil {
+		MAX_HISTORY_GAMES = maxHistoryGames
+	}
+
+	lambda.Start(StatsHandler(dynamoClient))
+	return nil
+}
@@ -0,0 +1,97 @@
+package query
+
+import (
+	"context"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// maximum number of keys dynamodb accepts in one batch get.
+	MAX_BATCH_GET = 100
+)
+
+type participationOutput struct {
+	GameId string `dynamodbav:"gameid"`
+}
+
+// FetchGamesBySubject fetches the latest games of the participant (up to limit, oldest games first) via the participant index.
+// games that no longer exist (e.g. expired games) are skipped. truncated reports whether older games were omitted.
+func FetchGamesBySubject(dynamoClient *dynamodb.Client, ctx context.Context, participationTableName, gameTableName, subject string, limit int) ([]GameOutput, bool, error) {
+	participations := []participationOutput{}
+	var lastEvaluatedKey map[string]types.AttributeValue
+	// one additional participation is read to detect whether the history is truncated.
+	for len(participations) <= limit {
+		output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
+			TableName: aws.String(participationTableName),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":subject": &types.AttributeValueMemberS{Value: subject},
+			},
+			KeyConditionExpression: aws.String("subject = :subject"),
+			ProjectionExpression:   aws.String("gameid"),
+			ScanIndexForward:       aws.Bool(false),
+			Limit:                  aws.Int32(int32(limit + 1 - len(participations))),
+			ExclusiveStartKey:      lastEvaluatedKey,
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		var page []participationOutput
+		if err := attributevalue.UnmarshalListOfMaps(output.Items, &page); err != nil {
+			return nil, false, err
+		}
+		participations = append(participations, page...)
+
+		lastEvaluatedKey = output.LastEvaluatedKey
+		if len(lastEvaluatedKey) < 1 {
+			break
+		}
+	}
+	truncated := len(participations) > limit
+	if truncated {
+		participations = participations[:limit]
+	}
+	slices.Reverse(participations)
+
+	gamesById := map[string]GameOutput{}
+	for start := 0; start < len(participations); start += MAX_BATCH_GET {
+		end := min(start+MAX_BATCH_GET, len(participations))
+		keys := []map[string]types.AttributeValue{}
+		for _, participation := range participations[start:end] {
+			keys = append(keys, map[string]types.AttributeValue{
+				"gameid": &types.AttributeValueMemberS{Value: participation.GameId},
+			})
+		}
+		requestItems := map[string]types.KeysAndAttributes{
+			gameTableName: {Keys: keys},
+		}
+		for len(requestItems) > 0 {
+			output, err := dynamoClient.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+				RequestItems: requestItems,
+			})
+			if err != nil {
+				return nil, false, err
+			}
+			var page []GameOutput
+			if err := attributevalue.UnmarshalListOfMaps(output.Responses[gameTableName], &page); err != nil {
+				return nil, false, err
+			}
+			for _, game := range page {
+				gamesById[game.GameId] = game
+			}
+			requestItems = output.UnprocessedKeys
+		}
+	}
+
+	games := []GameOutput{}
+	for _, participation := range participations {
+		if game, ok := gamesById[participation.GameId]; ok {
+			games = append(games, game)
+		}
+	}
+	return games, truncated, nil
+}
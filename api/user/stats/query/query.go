@@ -0,0 +1,28 @@
+// contains wrappers for database query functions.
+// main purpose is to abstract some boilerplate code
+// away from the handler.
+package query
+
+type UserOutput struct {
+	Subject  string `dynamodbav:"subject"`
+	Username string `dynamodbav:"username"`
+	Elo      int    `dynamodbav:"elo"`
+}
+
+type ParticipantOutput struct {
+	Subject   string `dynamodbav:"subject"`
+	Username  string `dynamodbav:"username"`
+	Team      int    `dynamodbav:"team"`
+	Placement int    `dynamodbav:"placement"`
+	Points    int    `dynamodbav:"points"`
+	Elo       int    `dynamodbav:"elo"`
+	EloUpdate int    `dynamodbav:"elo_update"`
+}
+
+type GameOutput struct {
+	GameId       string                       `dynamodbav:"gameid"`
+	PlayedAt     string                       `dynamodbav:"played_at"`
+	Readonly     bool                         `dynamodbav:"readonly"`
+	Reverted     bool                         `dynamodbav:"reverted"`
+	Participants map[string]ParticipantOutput `dynamodbav:"participants"`
+}
@@ -0,0 +1,119 @@
+// contains the aggregation of the user statistics.
+// statistics are computed on demand from the finalized games of the user.
+package stats
+
+import (
+	"sort"
+
+	"github.com/megakuul/leaderboard/api/user/stats/query"
+)
+
+type TeammateOutput struct {
+	Username string `json:"username"`
+	Games    int    `json:"games"`
+	Wins     int    `json:"wins"`
+}
+
+// StatsOutput contains the statistics of a user, truncated is set if older games exceeded the history limit and were not considered.
+type StatsOutput struct {
+	Username           string           `json:"username"`
+	Elo                int              `json:"elo"`
+	GamesPlayed        int              `json:"games_played"`
+	Wins               int              `json:"wins"`
+	Podiums            int              `json:"podiums"`
+	AveragePlacement   float64          `json:"average_placement"`
+	WinRate            float64          `json:"win_rate"`
+	TotalPoints        int              `json:"total_points"`
+	PeakElo            int              `json:"peak_elo"`
+	CurrentWinStreak   int              `json:"current_win_streak"`
+	LongestWinStreak   int              `json:"longest_win_streak"`
+	FavouriteTeammates []TeammateOutput `json:"favourite_teammates"`
+	Truncated          bool             `json:"truncated"`
+}
+
+// CalculateStats aggregates the statistics of the user from the specified games (oldest games first).
+// only finalized games that were not reverted are considered. a game is won with placement 1,
+// podiums are placements 1 to 3.
+func CalculateStats(user *query.UserOutput, games []query.GameOutput, maxTeammates int) *StatsOutput {
+	stats := &StatsOutput{
+		Username:           user.Username,
+		Elo:                user.Elo,
+		PeakElo:            user.Elo,
+		FavouriteTeammates: []TeammateOutput{},
+	}
+
+	totalPlacement := 0
+	teammates := map[string]*TeammateOutput{}
+	for _, game := range games {
+		if !game.Readonly || game.Reverted {
+			continue
+		}
+		var self *query.ParticipantOutput
+		for _, part := range game.Participants {
+			if part.Subject == user.Subject {
+				self = &part
+				break
+			}
+		}
+		if self == nil {
+			continue
+		}
+
+		won := self.Placement == 1
+		stats.GamesPlayed++
+		totalPlacement += self.Placement
+		stats.TotalPoints += self.Points
+		if won {
+			stats.Wins++
+			stats.CurrentWinStreak++
+			stats.LongestWinStreak = max(stats.LongestWinStreak, stats.CurrentWinStreak)
+		} else {
+			stats.CurrentWinStreak = 0
+		}
+		if self.Placement >= 1 && self.Placement <= 3 {
+			stats.Podiums++
+		}
+		// elo of the participant is the elo before the game.
+		stats.PeakElo = max(stats.PeakElo, self.Elo+self.EloUpdate)
+
+		for _, part := range game.Participants {
+			if part.Subject == user.Subject || part.Team != self.Team {
+				continue
+			}
+			teammate, ok := teammates[part.Subject]
+			if !ok {
+				teammate = &TeammateOutput{}
+				teammates[part.Subject] = teammate
+			}
+			// the latest known username is displayed.
+			teammate.Username = part.Username
+			teammate.Games++
+			if won {
+				teammate.Wins++
+			}
+		}
+	}
+
+	if stats.GamesPlayed > 0 {
+		stats.AveragePlacement = float64(totalPlacement) / float64(stats.GamesPlayed)
+		stats.WinRate = float64(stats.Wins) / float64(stats.GamesPlayed)
+	}
+
+	for _, teammate := range teammates {
+		stats.FavouriteTeammates = append(stats.FavouriteTeammates, *teammate)
+	}
+	sort.Slice(stats.FavouriteTeammates, func(i, j int) bool {
+		a, b := stats.FavouriteTeammates[i], stats.FavouriteTeammates[j]
+		if a.Games != b.Games {
+			return a.Games > b.Games
+		}
+		if a.Wins != b.Wins {
+			return a.Wins > b.Wins
+		}
+		return a.Username < b.Username
+	})
+	if len(stats.FavouriteTeammates) > maxTeammates {
+		stats.FavouriteTeammates = stats.FavouriteTeammates[:maxTeammates]
+	}
+	return stats
+}
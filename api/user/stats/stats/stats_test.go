@@ -0,0 +1,152 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/megakuul/leaderboard/api/user/stats/query"
+)
+
+func finalizedGame(participants ...query.ParticipantOutput) query.GameOutput {
+	game := query.GameOutput{Readonly: true, Participants: map[string]query.ParticipantOutput{}}
+	for _, part := range participants {
+		game.Participants[part.Subject] = part
+	}
+	return game
+}
+
+func TestCalculateStats(t *testing.T) {
+	user := &query.UserOutput{Subject: "a", Username: "alice", Elo: 1000}
+
+	tests := []struct {
+		name         string
+		games        []query.GameOutput
+		maxTeammates int
+		want         *StatsOutput
+	}{
+		{
+			name:         "no games",
+			games:        nil,
+			maxTeammates: 3,
+			want: &StatsOutput{
+				Username: "alice", Elo: 1000, PeakElo: 1000,
+				FavouriteTeammates: []TeammateOutput{},
+			},
+		},
+		{
+			name: "skips unfinalized, reverted and foreign games",
+			games: []query.GameOutput{
+				{Readonly: false, Participants: map[string]query.ParticipantOutput{
+					"a": {Subject: "a", Placement: 1, Points: 5},
+				}},
+				{Readonly: true, Reverted: true, Participants: map[string]query.ParticipantOutput{
+					"a": {Subject: "a", Placement: 1, Points: 5},
+				}},
+				finalizedGame(query.ParticipantOutput{Subject: "b", Placement: 1, Points: 5}),
+			},
+			maxTeammates: 3,
+			want: &StatsOutput{
+				Username: "alice", Elo: 1000, PeakElo: 1000,
+				FavouriteTeammates: []TeammateOutput{},
+			},
+		},
+		{
+			name: "streaks, podiums and peak elo",
+			games: []query.GameOutput{
+				finalizedGame(query.ParticipantOutput{Subject: "a", Placement: 1, Points: 3, Elo: 1000, EloUpdate: 20}),
+				finalizedGame(query.ParticipantOutput{Subject: "a", Placement: 1, Points: 3, Elo: 1020, EloUpdate: 15}),
+				finalizedGame(query.ParticipantOutput{Subject: "a", Placement: 3, Points: 1, Elo: 1035, EloUpdate: -20}),
+				finalizedGame(query.ParticipantOutput{Subject: "a", Placement: 4, Points: 0, Elo: 1015, EloUpdate: -15}),
+				finalizedGame(query.ParticipantOutput{Subject: "a", Placement: 1, Points: 3, Elo: 1000, EloUpdate: 0}),
+			},
+			maxTeammates: 3,
+			want: &StatsOutput{
+				Username: "alice", Elo: 1000, PeakElo: 1035,
+				GamesPlayed: 5, Wins: 3, Podiums: 4, TotalPoints: 10,
+				AveragePlacement: 2, WinRate: 0.6,
+				CurrentWinStreak: 1, LongestWinStreak: 2,
+				FavouriteTeammates: []TeammateOutput{},
+			},
+		},
+		{
+			name: "shared placement counts as win for every participant",
+			games: []query.GameOutput{
+				finalizedGame(
+					query.ParticipantOutput{Subject: "a", Team: 1, Placement: 1},
+					query.ParticipantOutput{Subject: "b", Team: 2, Placement: 1},
+				),
+			},
+			maxTeammates: 3,
+			want: &StatsOutput{
+				Username: "alice", Elo: 1000, PeakElo: 1000,
+				GamesPlayed: 1, Wins: 1, Podiums: 1,
+				AveragePlacement: 1, WinRate: 1,
+				CurrentWinStreak: 1, LongestWinStreak: 1,
+				FavouriteTeammates: []TeammateOutput{},
+			},
+		},
+		{
+			name: "teammates are sorted by games and wins and capped",
+			games: []query.GameOutput{
+				finalizedGame(
+					query.ParticipantOutput{Subject: "a", Team: 1, Placement: 1},
+					query.ParticipantOutput{Subject: "b", Username: "bob", Team: 1, Placement: 1},
+					query.ParticipantOutput{Subject: "c", Username: "carol", Team: 1, Placement: 1},
+					query.ParticipantOutput{Subject: "d", Username: "dave", Team: 2, Placement: 2},
+				),
+				finalizedGame(
+					query.ParticipantOutput{Subject: "a", Team: 2, Placement: 2},
+					query.ParticipantOutput{Subject: "b", Username: "bobby", Team: 2, Placement: 2},
+					query.ParticipantOutput{Subject: "d", Username: "dave", Team: 1, Placement: 1},
+				),
+				finalizedGame(
+					query.ParticipantOutput{Subject: "a", Team: 1, Placement: 1},
+					query.ParticipantOutput{Subject: "c", Username: "carol", Team: 1, Placement: 1},
+					query.ParticipantOutput{Subject: "e", Username: "erin", Team: 1, Placement: 1},
+				),
+			},
+			maxTeammates: 2,
+			want: &StatsOutput{
+				Username: "alice", Elo: 1000, PeakElo: 1000,
+				GamesPlayed: 3, Wins: 2, Podiums: 3,
+				AveragePlacement: 4.0 / 3.0, WinRate: 2.0 / 3.0,
+				CurrentWinStreak: 1, LongestWinStreak: 1,
+				FavouriteTeammates: []TeammateOutput{
+					{Username: "carol", Games: 2, Wins: 2},
+					{Username: "bobby", Games: 2, Wins: 1},
+				},
+			},
+		},
+		{
+			name: "deleted teammates are kept apart by their anonymous id",
+			games: []query.GameOutput{
+				finalizedGame(
+					query.ParticipantOutput{Subject: "a", Team: 1, Placement: 1},
+					query.ParticipantOutput{Subject: "deleted-01", Username: "[deleted]", Team: 1, Placement: 1},
+				),
+				finalizedGame(
+					query.ParticipantOutput{Subject: "a", Team: 1, Placement: 1},
+					query.ParticipantOutput{Subject: "deleted-02", Username: "[deleted]", Team: 1, Placement: 1},
+				),
+			},
+			maxTeammates: 3,
+			want: &StatsOutput{
+				Username: "alice", Elo: 1000, PeakElo: 1000,
+				GamesPlayed: 2, Wins: 2, Podiums: 2,
+				AveragePlacement: 1, WinRate: 1,
+				CurrentWinStreak: 2, LongestWinStreak: 2,
+				FavouriteTeammates: []TeammateOutput{
+					{Username: "[deleted]", Games: 1, Wins: 1},
+					{Username: "[deleted]", Games: 1, Wins: 1},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CalculateStats(user, tt.games, tt.maxTeammates); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CalculateStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
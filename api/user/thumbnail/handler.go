@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/megakuul/leaderboard/api/user/thumbnail/storage"
+	"github.com/megakuul/leaderboard/api/user/thumbnail/thumbnail"
+	"github.com/megakuul/leaderboard/api/user/thumbnail/update"
+)
+
+const (
+	// uploads are stored below this prefix (avatars/uploads/<subject>/<avatarid>) until they are processed.
+	UPLOAD_PREFIX = "avatars/uploads/"
+	// thumbnails are stored below this prefix (avatars/<avatarid>-<size>.png), it is served by the cdn under AVATAR_BASE_URL.
+	AVATAR_PREFIX = "avatars/"
+)
+
+type objectCreatedDetail struct {
+	Bucket struct {
+		Name string `json:"name"`
+	} `json:"bucket"`
+	Object struct {
+		Key string `json:"key"`
+	} `json:"object"`
+}
+
+func ThumbnailHandler(dynamoClient *dynamodb.Client, s3Client *s3.Client) func(context.Context, events.EventBridgeEvent) error {
+	return func(ctx context.Context, event events.EventBridgeEvent) error {
+		if err := runThumbnailHandler(dynamoClient, s3Client, &event, ctx); err != nil {
+			log.Printf("ERROR EVENT %s: %v\n", event.ID, err)
+			// failed invocations are retried by the asynchronous invocation.
+			return err
+		}
+		return nil
+	}
+}
+
+func runThumbnailHandler(dynamoClient *dynamodb.Client, s3Client *s3.Client, event *events.EventBridgeEvent, ctx context.Context) error {
+	var detail objectCreatedDetail
+	if err := json.Unmarshal(event.Detail, &detail); err != nil {
+		return fmt.Errorf("failed to deserialize event detail: %v", err)
+	}
+	if detail.Bucket.Name != AVATARBUCKET {
+		return nil
+	}
+	uploadKey := detail.Object.Key
+	subject, avatarId, ok := parseUploadKey(uploadKey)
+	if !ok {
+		log.Printf("WARNING: ignoring object %s: not an avatar upload\n", uploadKey)
+		return nil
+	}
+
+	data, ok, err := storage.FetchUpload(s3Client, ctx, AVATARBUCKET, uploadKey, int64(MAX_UPLOAD_BYTES))
+	if err != nil {
+		return fmt.Errorf("failed to fetch upload: %v", err)
+	}
+	if !ok {
+		log.Printf("WARNING: rejecting avatar of %s: maximum avatar size is %d bytes\n", subject, MAX_UPLOAD_BYTES)
+		return storage.DeleteObjects(s3Client, ctx, AVATARBUCKET, []string{uploadKey})
+	}
+
+	thumbnails, err := thumbnail.Generate(data, MAX_IMAGE_PIXELS)
+	if errors.Is(err, thumbnail.ErrInvalidImage) {
+		log.Printf("WARNING: rejecting avatar of %s: %v\n", subject, err)
+		return storage.DeleteObjects(s3Client, ctx, AVATARBUCKET, []string{uploadKey})
+	} else if err != nil {
+		return fmt.Errorf("failed to generate thumbnails: %v", err)
+	}
+
+	for size, data := range thumbnails {
+		if err := storage.PutThumbnail(s3Client, ctx, AVATARBUCKET, thumbnailKey(avatarId, size), data); err != nil {
+			return fmt.Errorf("failed to put thumbnail: %v", err)
+		}
+	}
+
+	// the largest thumbnail is used as iconurl.
+	iconUrl := AVATAR_BASE_URL + strings.TrimPrefix(thumbnailKey(avatarId, thumbnail.SIZES[len(thumbnail.SIZES)-1]), AVATAR_PREFIX)
+	previousAvatarId, err := update.SetAvatar(dynamoClient, ctx, USERTABLE, subject, iconUrl, avatarId)
+	if errors.Is(err, update.ErrUserNotFound) {
+		log.Printf("WARNING: rejecting avatar of %s: user not found\n", subject)
+		return storage.DeleteObjects(s3Client, ctx, AVATARBUCKET, append(thumbnailKeys(avatarId), uploadKey))
+	} else if err != nil {
+		return fmt.Errorf("failed to set iconurl: %v", err)
+	}
+
+	obsoleteKeys := []string{uploadKey}
+	// thumbnails of the previous avatar of the user are no longer referenced.
+	if previousAvatarId != "" && previousAvatarId != avatarId {
+		obsoleteKeys = append(obsoleteKeys, thumbnailKeys(previousAvatarId)...)
+	}
+	if err := storage.DeleteObjects(s3Client, ctx, AVATARBUCKET, obsoleteKeys); err != nil {
+		return fmt.Errorf("failed to delete obsolete objects: %v", err)
+	}
+	return nil
+}
+
+// parseUploadKey extracts subject and avatar id from the upload key (avatars/uploads/<subject>/<avatarid>).
+func parseUploadKey(key string) (string, string, bool) {
+	if !strings.HasPrefix(key, UPLOAD_PREFIX) {
+		return "", "", false
+	}
+	parts := strings.Split(strings.TrimPrefix(key, UPLOAD_PREFIX), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+func thumbnailKey(avatarId string, size int) string {
+	return fmt.Sprintf("%s%s-%d.png", AVATAR_PREFIX, avatarId, size)
+}
+
+func thumbnailKeys(avatarId string) []string {
+	keys := []string{}
+	for _, size := range thumbnail.SIZES {
+		keys = append(keys, thumbnailKey(avatarId, size))
+	}
+	return keys
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+var (
+	REGION           = os.Getenv("AWS_REGION")
+	USERTABLE        = os.Getenv("USERTABLE")
+	AVATARBUCKET     = os.Getenv("AVATARBUCKET")
+	AVATAR_BASE_URL  = os.Getenv("AVATAR_BASE_URL")
+	MAX_UPLOAD_BYTES = 2097152  // default 2097152
+	MAX_IMAGE_PIXELS = 16777216 // default 16777216
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatalf("ERROR INITIALIZATION: %v\n", err)
+	}
+}
+
+func run() error {
+	awsConfig, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(REGION))
+	if err != nil {
+		return fmt.Errorf("failed to load aws config: %v", err)
+	}
+	dynamoClient := dynamodb.NewFromConfig(awsConfig)
+	s3Client := s3.NewFromConfig(awsConfig)
+
+	if maxUploadBytes, err := strconv.Atoi(os.Getenv("MAX_UPLOAD_BYTES")); err == nil {
+		MAX_UPLOAD_BYTES = maxUploadBytes
+	}
+	if maxImagePixels, err := strconv.Atoi(os.Getenv("MAX_IMAGE_PIXELS")); err == nil {
+		MAX_IMAGE_PIXELS = maxImagePixels
+	}
+	if AVATAR_BASE_URL == "" {
+		return fmt.Errorf("no AVATAR_BASE_URL provided")
+	}
+
+	lambda.Start(ThumbnailHandler(dynamoClient, s3Client))
+	return nil
+}
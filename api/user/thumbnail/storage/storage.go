@@ -0,0 +1,79 @@
+// contains wrappers for the avatar object storage functions.
+// main purpose is to abstract some boilerplate code
+// away from the handler.
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// FetchUpload reads the uploaded object, uploads larger than maxBytes are rejected without reading them completely.
+func FetchUpload(s3Client *s3.Client, ctx context.Context, bucketName, key string, maxBytes int64) ([]byte, bool, error) {
+	output, err := s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	defer output.Body.Close()
+
+	if aws.ToInt64(output.ContentLength) > maxBytes {
+		return nil, false, nil
+	}
+	data, err := io.ReadAll(io.LimitReader(output.Body, maxBytes+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, false, nil
+	}
+	return data, true, nil
+}
+
+// PutThumbnail writes the png thumbnail. thumbnails are immutable (every upload has a new id), therefore they are cached indefinitely.
+func PutThumbnail(s3Client *s3.Client, ctx context.Context, bucketName, key string, data []byte) error {
+	_, err := s3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:       aws.String(bucketName),
+		Key:          aws.String(key),
+		Body:         bytes.NewReader(data),
+		ContentType:  aws.String("image/png"),
+		CacheControl: aws.String("public, max-age=31536000, immutable"),
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteObjects deletes the objects, missing objects are ignored.
+func DeleteObjects(s3Client *s3.Client, ctx context.Context, bucketName string, keys []string) error {
+	if len(keys) < 1 {
+		return nil
+	}
+	objects := []types.ObjectIdentifier{}
+	for _, key := range keys {
+		objects = append(objects, types.ObjectIdentifier{Key: aws.String(key)})
+	}
+	output, err := s3Client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+		Bucket: aws.String(bucketName),
+		Delete: &types.Delete{
+			Objects: objects,
+			Quiet:   aws.Bool(true),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if len(output.Errors) > 0 {
+		return fmt.Errorf("failed to delete %s: %s", aws.ToString(output.Errors[0].Key), aws.ToString(output.Errors[0].Message))
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// contains the validation and the thumbnail generation of avatar images.
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// sizes (width and height in pixels) of the generated thumbnails.
+var SIZES = []int{64, 256}
+
+// image types accepted as avatar, detected by the content of the image (not by the declared content type).
+var ALLOWED_CONTENT_TYPES = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// ErrInvalidImage indicates that the upload is no valid avatar image, it is not retried.
+var ErrInvalidImage = errors.New("invalid image")
+
+// Generate validates the image and creates square png thumbnails of all SIZES.
+// the dimensions are validated before the image is decoded, so that oversized images are rejected without decoding them.
+// the image is cropped to a centered square and scaled down (or up) to the thumbnail size.
+func Generate(data []byte, maxPixels int) (map[int][]byte, error) {
+	contentType := http.DetectContentType(data)
+	if !ALLOWED_CONTENT_TYPES[contentType] {
+		return nil, fmt.Errorf("%w: unsupported content type %s", ErrInvalidImage, contentType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if config.Width < 1 || config.Height < 1 || config.Width*config.Height > maxPixels {
+		return nil, fmt.Errorf("%w: image must have between 1 and %d pixels", ErrInvalidImage, maxPixels)
+	}
+
+	source, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	bounds := source.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	crop := image.Rect(0, 0, side, side).Add(image.Pt(
+		bounds.Min.X+(bounds.Dx()-side)/2,
+		bounds.Min.Y+(bounds.Dy()-side)/2,
+	))
+
+	thumbnails := map[int][]byte{}
+	for _, size := range SIZES {
+		thumbnail := image.NewRGBA(image.Rect(0, 0, size, size))
+		draw.CatmullRom.Scale(thumbnail, thumbnail.Bounds(), source, crop, draw.Src, nil)
+
+		var buffer bytes.Buffer
+		if err := png.Encode(&buffer, thumbnail); err != nil {
+			return nil, fmt.Errorf("failed to encode thumbnail: %v", err)
+		}
+		thumbnails[size] = buffer.Bytes()
+	}
+	return thumbnails, nil
+}
@@ -0,0 +1,50 @@
+// contains wrappers for database update functions.
+// main purpose is to abstract some boilerplate code
+// away from the handler.
+package update
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrUserNotFound indicates that the avatar was uploaded by a subject without user.
+var ErrUserNotFound = errors.New("user not found")
+
+// SetAvatar sets the iconurl of the user to the processed avatar and records the avatar id as owned by the user.
+// the previous avatar id is returned, only thumbnails of recorded avatar ids are deleted (never ids parsed from an iconurl).
+func SetAvatar(dynamoClient *dynamodb.Client, ctx context.Context, tableName, subject, iconUrl, avatarId string) (string, error) {
+	output, err := dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"subject": &types.AttributeValueMemberS{Value: subject},
+		},
+		ExpressionAttributeNames: map[string]string{
+			"#iconurl":   "iconurl",
+			"#avatar_id": "avatar_id",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":iconurl":   &types.AttributeValueMemberS{Value: iconUrl},
+			":avatar_id": &types.AttributeValueMemberS{Value: avatarId},
+		},
+		ConditionExpression: aws.String("attribute_exists(subject)"), // prevent it to upsert if not existent
+		UpdateExpression:    aws.String("SET #iconurl = :iconurl, #avatar_id = :avatar_id"),
+		ReturnValues:        types.ReturnValueUpdatedOld,
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return "", ErrUserNotFound
+		}
+		return "", err
+	}
+	previous, ok := output.Attributes["avatar_id"].(*types.AttributeValueMemberS)
+	if !ok {
+		return "", nil
+	}
+	return previous.Value, nil
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -66,11 +67,25 @@ func runUpdatehandler(dynamoClient *dynamodb.Client, request *events.APIGatewayV
 		return nil, http.StatusBadRequest, fmt.Errorf("maximum title length is %d", MAX_TITLE_LENGTH)
 	}
 
-	user, err := update.UpsertUser(dynamoClient, ctx, BASEELO, USERTABLE, sub, REGION, request.RequestContext.Authorizer.JWT.Claims, &req.UserUpdates)
+	avatarId, err := update.FetchAvatarId(dynamoClient, ctx, USERTABLE, sub)
 	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch avatar: %v", err)
+	}
+	if err := update.ValidateIconURL(req.UserUpdates.IconURL, avatarId, AVATAR_BASE_URL, ICON_HOST_ALLOWLIST); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	user, err := update.UpsertUser(dynamoClient, ctx, BASEELO, USERTABLE, USERNAMERESERVATIONTABLE, sub, REGION, request.RequestContext.Authorizer.JWT.Claims, &req.UserUpdates)
+	if errors.Is(err, update.ErrUsernameTaken) || errors.Is(err, update.ErrConcurrentUpdate) {
+		return nil, http.StatusConflict, fmt.Errorf("failed to upsert user: %v", err)
+	} else if err != nil {
 		return nil, http.StatusBadRequest, fmt.Errorf("failed to upsert user: %v", err)
 	}
 
+	if err := update.RecordUsername(dynamoClient, ctx, USERNAMEHISTORYTABLE, user.Username, sub); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to record username: %v", err)
+	}
+
 	return &UpdateResponse{
 		Message:     "successfully updated user",
 		UpdatedUser: *user,
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -12,9 +13,13 @@ import (
 )
 
 var (
-	REGION    = os.Getenv("AWS_REGION")
-	USERTABLE = os.Getenv("USERTABLE")
-	BASEELO   = os.Getenv("BASEELO")
+	REGION                   = os.Getenv("AWS_REGION")
+	USERTABLE                = os.Getenv("USERTABLE")
+	USERNAMEHISTORYTABLE     = os.Getenv("USERNAMEHISTORYTABLE")
+	USERNAMERESERVATIONTABLE = os.Getenv("USERNAMERESERVATIONTABLE")
+	BASEELO                  = os.Getenv("BASEELO")
+	AVATAR_BASE_URL          = os.Getenv("AVATAR_BASE_URL")
+	ICON_HOST_ALLOWLIST      = []string{} // default none
 )
 
 func main() {
@@ -30,6 +35,15 @@ func run() error {
 	}
 	dynamoClient := dynamodb.NewFromConfig(awsConfig)
 
+	// allowed icon hosts are specified as comma separated list (e.g. "gravatar.com,avatars.githubusercontent.com").
+	if allowlist := os.Getenv("ICON_HOST_ALLOWLIST"); allowlist != "" {
+		for _, host := range strings.Split(allowlist, ",") {
+			if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+				ICON_HOST_ALLOWLIST = append(ICON_HOST_ALLOWLIST, host)
+			}
+		}
+	}
+
 	lambda.Start(UpdateHandler(dynamoClient))
 	return nil
 }
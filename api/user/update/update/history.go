@@ -0,0 +1,28 @@
+package update
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// RecordUsername records the username in the username history, so that the username still resolves to the
+// subject after the user changed it. if the username is taken over by another user, the latest owner is recorded.
+func RecordUsername(dynamoClient *dynamodb.Client, ctx context.Context, tableName, username, subject string) error {
+	_, err := dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(tableName),
+		Item: map[string]types.AttributeValue{
+			"username":    &types.AttributeValueMemberS{Value: username},
+			"subject":     &types.AttributeValueMemberS{Value: subject},
+			"recorded_at": &types.AttributeValueMemberN{Value: strconv.Itoa(int(time.Now().Unix()))},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrInvalidIconURL indicates that the iconurl is neither a managed avatar nor hosted on an allowed host.
+var ErrInvalidIconURL = errors.New("invalid iconurl")
+
+// ValidateIconURL checks that the iconurl references the managed avatar of the user (uploaded via /api/user/avatar) or an allowed host.
+// iconurls are displayed to every visitor, arbitrary urls would allow tracking pixels and mixed content.
+// managed avatars of other users are rejected, avatarId is the avatar id recorded on the user by the thumbnail function.
+// an empty iconurl removes the icon and is always valid.
+func ValidateIconURL(iconUrl, avatarId, avatarBaseUrl string, allowedHosts []string) error {
+	if iconUrl == "" {
+		return nil
+	}
+	parsedUrl, err := url.Parse(iconUrl)
+	if err != nil || parsedUrl.Scheme != "https" || parsedUrl.User != nil || parsedUrl.Host == "" {
+		return fmt.Errorf("%w: expected https url", ErrInvalidIconURL)
+	}
+
+	if avatarBaseUrl != "" && strings.HasPrefix(iconUrl, avatarBaseUrl) {
+		// managed avatars are stored directly below the base url (<avatarid>-<size>.png).
+		name := strings.TrimPrefix(iconUrl, avatarBaseUrl)
+		size, ok := strings.CutSuffix(strings.TrimPrefix(name, avatarId+"-"), ".png")
+		if avatarId != "" && strings.HasPrefix(name, avatarId+"-") && ok && isDigits(size) {
+			return nil
+		}
+		return fmt.Errorf("%w: unknown avatar", ErrInvalidIconURL)
+	}
+
+	host := strings.ToLower(parsedUrl.Hostname())
+	for _, allowedHost := range allowedHosts {
+		if host == allowedHost {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: upload an avatar or use an icon of an allowed host (%s)", ErrInvalidIconURL, strings.Join(allowedHosts, ", "))
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// FetchAvatarId fetches the avatar id recorded on the user, "" is returned if the user has no managed avatar.
+func FetchAvatarId(dynamoClient *dynamodb.Client, ctx context.Context, tableName, subject string) (string, error) {
+	output, err := dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"subject": &types.AttributeValueMemberS{Value: subject},
+		},
+		ExpressionAttributeNames: map[string]string{
+			"#avatar_id": "avatar_id",
+		},
+		ProjectionExpression: aws.String("#avatar_id"),
+		ConsistentRead:       aws.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+	if attr, ok := output.Item["avatar_id"].(*types.AttributeValueMemberS); ok {
+		return attr.Value, nil
+	}
+	return "", nil
+}
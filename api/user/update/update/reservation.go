@@ -0,0 +1,118 @@
+package update
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrUsernameTaken indicates that the username is reserved by another user.
+var ErrUsernameTaken = errors.New("username is already taken by another user")
+
+// ErrConcurrentUpdate indicates that the username of the user was changed by a concurrent update.
+var ErrConcurrentUpdate = errors.New("user was updated concurrently")
+
+// fetchUsername fetches the current username of the user, "" is returned if the user has no username yet.
+func fetchUsername(dynamoClient *dynamodb.Client, ctx context.Context, userTableName, subject string) (string, error) {
+	output, err := dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(userTableName),
+		Key: map[string]types.AttributeValue{
+			"subject": &types.AttributeValueMemberS{Value: subject},
+		},
+		ExpressionAttributeNames: map[string]string{
+			"#username": "username",
+		},
+		ProjectionExpression: aws.String("#username"),
+		ConsistentRead:       aws.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+	if attr, ok := output.Item["username"].(*types.AttributeValueMemberS); ok {
+		return attr.Value, nil
+	}
+	return "", nil
+}
+
+// fetchReservationHolder fetches the subject that holds the reservation of the normalized username, "" is returned if the username is not reserved.
+func fetchReservationHolder(dynamoClient *dynamodb.Client, ctx context.Context, reservationTableName, username string) (string, error) {
+	output, err := dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(reservationTableName),
+		Key: map[string]types.AttributeValue{
+			"username": &types.AttributeValueMemberS{Value: username},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+	if attr, ok := output.Item["subject"].(*types.AttributeValueMemberS); ok {
+		return attr.Value, nil
+	}
+	return "", nil
+}
+
+// reserveUsernameItems creates the transaction item that reserves the username for the subject and the item that releases the previous username.
+// usernames are reserved by their normalized key (like the search), so that usernames that only differ in case or whitespace can not coexist.
+// the previous reservation is only released if it is held by the subject (otherwise the release is nil), reservations of other users
+// (duplicates created before usernames were reserved) are left untouched.
+func reserveUsernameItems(dynamoClient *dynamodb.Client, ctx context.Context, reservationTableName, subject, previousUsername, username string) (types.TransactWriteItem, *types.TransactWriteItem, error) {
+	reservationKey, previousReservationKey := NormalizeSearchKey(username), NormalizeSearchKey(previousUsername)
+	reservation := types.TransactWriteItem{
+		Put: &types.Put{
+			TableName: aws.String(reservationTableName),
+			Item: map[string]types.AttributeValue{
+				"username": &types.AttributeValueMemberS{Value: reservationKey},
+				"subject":  &types.AttributeValueMemberS{Value: subject},
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":subject": &types.AttributeValueMemberS{Value: subject},
+			},
+			ConditionExpression: aws.String("attribute_not_exists(username) OR subject = :subject"),
+		},
+	}
+	if previousReservationKey == "" || previousReservationKey == reservationKey {
+		return reservation, nil, nil
+	}
+
+	holder, err := fetchReservationHolder(dynamoClient, ctx, reservationTableName, previousReservationKey)
+	if err != nil {
+		return reservation, nil, err
+	}
+	if holder != subject {
+		return reservation, nil, nil
+	}
+	return reservation, &types.TransactWriteItem{
+		Delete: &types.Delete{
+			TableName: aws.String(reservationTableName),
+			Key: map[string]types.AttributeValue{
+				"username": &types.AttributeValueMemberS{Value: previousReservationKey},
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":subject": &types.AttributeValueMemberS{Value: subject},
+			},
+			ConditionExpression: aws.String("subject = :subject"),
+		},
+	}, nil
+}
+
+// reservationError maps the cancellation reasons of the upsert transaction to ErrUsernameTaken (reservation put)
+// and ErrConcurrentUpdate (user update or reservation release).
+func reservationError(err error, itemCount int) error {
+	var cancelErr *types.TransactionCanceledException
+	if !errors.As(err, &cancelErr) || len(cancelErr.CancellationReasons) != itemCount {
+		return err
+	}
+	if aws.ToString(cancelErr.CancellationReasons[0].Code) == "ConditionalCheckFailed" {
+		return ErrUsernameTaken
+	}
+	for _, reason := range cancelErr.CancellationReasons[1:] {
+		if aws.ToString(reason.Code) == "ConditionalCheckFailed" {
+			return ErrConcurrentUpdate
+		}
+	}
+	return err
+}
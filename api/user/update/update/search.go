@@ -0,0 +1,21 @@
+package update
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// NormalizeSearchKey normalizes the username for the case-insensitive search.
+// the username is lowercased and whitespace is collapsed to single spaces.
+func NormalizeSearchKey(username string) string {
+	return strings.Join(strings.Fields(strings.ToLower(username)), " ")
+}
+
+// SearchPrefix returns the partition of the search_gsi for the normalized search key (its first character).
+func SearchPrefix(searchKey string) string {
+	r, _ := utf8.DecodeRuneInString(searchKey)
+	if r == utf8.RuneError {
+		return ""
+	}
+	return string(r)
+}
@@ -6,6 +6,7 @@ package update
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -17,11 +18,13 @@ type UserInput struct {
 	Title    string `json:"title"`
 	IconURL  string `json:"iconurl"`
 	Disabled bool   `json:"disabled"`
+	Private  bool   `json:"private"`
 }
 
 type UserOutput struct {
 	Username string `dynamodbav:"username" json:"username"`
 	Disabled bool   `dynamodbav:"disabled" json:"disabled"`
+	Private  bool   `dynamodbav:"private" json:"private"`
 	Region   string `dynamodbav:"user_region" json:"region"`
 	Title    string `dynamodbav:"title" json:"title"`
 	Email    string `dynamodbav:"email" json:"email"`
@@ -29,59 +32,103 @@ type UserOutput struct {
 	Elo      int    `dynamodbav:"elo" json:"elo"`
 }
 
-func UpsertUser(dynamoClient *dynamodb.Client, ctx context.Context, baseElo, tableName, subject, region string, claims map[string]string, userUpdate *UserInput) (*UserOutput, error) {
-	output, err := dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
-		TableName: aws.String(tableName),
-		Key: map[string]types.AttributeValue{
-			"subject": &types.AttributeValueMemberS{Value: subject},
-		},
-		ExpressionAttributeNames: map[string]string{
-			"#username":    "username",
-			"#title":       "title",
-			"#disabled":    "disabled",
-			"#iconurl":     "iconurl",
-			"#email":       "email",
-			"#user_region": "user_region",
-		},
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":username":    &types.AttributeValueMemberS{Value: claims["preferred_username"]},
-			":disabled":    &types.AttributeValueMemberBOOL{Value: userUpdate.Disabled},
-			":title":       &types.AttributeValueMemberS{Value: userUpdate.Title},
-			":iconurl":     &types.AttributeValueMemberS{Value: userUpdate.IconURL},
-			":email":       &types.AttributeValueMemberS{Value: claims["email"]},
-			":user_region": &types.AttributeValueMemberS{Value: region},
-		},
-		UpdateExpression: aws.String("SET #username = :username, #disabled = :disabled, #title = :title, #iconurl = :iconurl, #email = :email, #user_region = :user_region"),
-		ReturnValues:     types.ReturnValueAllNew,
-	})
+// UpsertUser updates the user with the data from the identity-provider and the user updates.
+// the username reservation and the user update are written in one transaction with a check on the current username,
+// ErrUsernameTaken is returned if another user holds the username, ErrConcurrentUpdate if the user was renamed concurrently.
+// disabled and private users are not listed, their index attributes (region and search keys) are removed
+// which drops them from the sparse region_gsi and search_gsi.
+func UpsertUser(dynamoClient *dynamodb.Client, ctx context.Context, baseElo, tableName, reservationTableName, subject, region string, claims map[string]string, userUpdate *UserInput) (*UserOutput, error) {
+	username := claims["preferred_username"]
+	if strings.TrimSpace(username) == "" {
+		return nil, fmt.Errorf("no preferred_username claim in the ID token")
+	}
+
+	previousUsername, err := fetchUsername(dynamoClient, ctx, tableName, subject)
+	if err != nil {
+		return nil, err
+	}
+	reservation, release, err := reserveUsernameItems(dynamoClient, ctx, reservationTableName, subject, previousUsername, username)
 	if err != nil {
 		return nil, err
 	}
 
-	// If elo attribute is not set (new users) it is upserted with the BASEELO
-	if _, ok := output.Attributes["elo"]; !ok {
-		output, err = dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+	// the normalized username is indexed in the search_gsi, partitioned by its first character.
+	searchKey := NormalizeSearchKey(username)
+
+	expressionAttributeNames := map[string]string{
+		"#username":      "username",
+		"#search_prefix": "search_prefix",
+		"#search_key":    "search_key",
+		"#title":         "title",
+		"#disabled":      "disabled",
+		"#private":       "private",
+		"#iconurl":       "iconurl",
+		"#email":         "email",
+		"#user_region":   "user_region",
+		"#elo":           "elo",
+	}
+	expressionAttributeValues := map[string]types.AttributeValue{
+		":username": &types.AttributeValueMemberS{Value: username},
+		":disabled": &types.AttributeValueMemberBOOL{Value: userUpdate.Disabled},
+		":private":  &types.AttributeValueMemberBOOL{Value: userUpdate.Private},
+		":title":    &types.AttributeValueMemberS{Value: userUpdate.Title},
+		":iconurl":  &types.AttributeValueMemberS{Value: userUpdate.IconURL},
+		":email":    &types.AttributeValueMemberS{Value: claims["email"]},
+		":elo":      &types.AttributeValueMemberN{Value: baseElo},
+	}
+	// new users are initialized with the BASEELO.
+	updateExpression := "SET #username = :username, #disabled = :disabled, #private = :private, #title = :title, #iconurl = :iconurl, #email = :email, #elo = if_not_exists(#elo, :elo)"
+	if userUpdate.Disabled || userUpdate.Private {
+		updateExpression += " REMOVE #user_region, #search_prefix, #search_key"
+	} else {
+		// dynamodb rejects unused expression attributes, therefore they are only set if required.
+		expressionAttributeValues[":search_prefix"] = &types.AttributeValueMemberS{Value: SearchPrefix(searchKey)}
+		expressionAttributeValues[":search_key"] = &types.AttributeValueMemberS{Value: searchKey}
+		expressionAttributeValues[":user_region"] = &types.AttributeValueMemberS{Value: region}
+		updateExpression += ", #search_prefix = :search_prefix, #search_key = :search_key, #user_region = :user_region"
+	}
+	conditionExpression := "attribute_not_exists(#username)"
+	if previousUsername != "" {
+		expressionAttributeValues[":previous_username"] = &types.AttributeValueMemberS{Value: previousUsername}
+		conditionExpression = "#username = :previous_username"
+	}
+
+	transactItems := []types.TransactWriteItem{reservation, {
+		Update: &types.Update{
 			TableName: aws.String(tableName),
 			Key: map[string]types.AttributeValue{
 				"subject": &types.AttributeValueMemberS{Value: subject},
 			},
-			ExpressionAttributeNames: map[string]string{
-				"#elo": "elo",
-			},
-			ExpressionAttributeValues: map[string]types.AttributeValue{
-				":elo": &types.AttributeValueMemberN{Value: baseElo},
-			},
-			UpdateExpression:    aws.String("SET #elo = :elo"),
-			ConditionExpression: aws.String("attribute_not_exists(elo)"),
-			ReturnValues:        types.ReturnValueAllNew,
-		})
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate base elo: %v", err)
-		}
+			ExpressionAttributeNames:  expressionAttributeNames,
+			ExpressionAttributeValues: expressionAttributeValues,
+			ConditionExpression:       aws.String(conditionExpression),
+			UpdateExpression:          aws.String(updateExpression),
+		},
+	}}
+	if release != nil {
+		transactItems = append(transactItems, *release)
+	}
+	_, err = dynamoClient.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		return nil, reservationError(err, len(transactItems))
+	}
+
+	// transactions return no attributes, therefore the updated user is read afterwards.
+	output, err := dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"subject": &types.AttributeValueMemberS{Value: subject},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch updated user: %v", err)
 	}
 
 	var user UserOutput
-	if err := attributevalue.UnmarshalMap(output.Attributes, &user); err != nil {
+	if err := attributevalue.UnmarshalMap(output.Item, &user); err != nil {
 		return nil, fmt.Errorf("failed to deserialize upsert output")
 	}
 
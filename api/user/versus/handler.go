@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/megakuul/leaderboard/api/user/versus/query"
+	"github.com/megakuul/leaderboard/api/user/versus/versus"
+)
+
+type VersusResponse struct {
+	Message string               `json:"message"`
+	Versus  *versus.VersusOutput `json:"versus"`
+}
+
+func VersusHandler(dynamoClient *dynamodb.Client) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		response, code, err := runVersusHandler(dynamoClient, &request, ctx)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: code,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       err.Error(),
+			}, nil
+		}
+		serializedResponse, err := json.Marshal(&response)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: http.StatusInternalServerError,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       "failed to serialize response",
+			}, nil
+		}
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode: code,
+			Headers:    map[string]string{"Content-Type": "application/json"},
+			Body:       string(serializedResponse),
+		}, nil
+	}
+}
+
+func runVersusHandler(dynamoClient *dynamodb.Client, request *events.APIGatewayV2HTTPRequest, ctx context.Context) (*VersusResponse, int, error) {
+	usernameA := request.QueryStringParameters["a"]
+	usernameB := request.QueryStringParameters["b"]
+	if usernameA == "" || usernameB == "" {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to fetch record: both usernames 'a' and 'b' must be provided")
+	}
+	if usernameA == usernameB {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to fetch record: usernames 'a' and 'b' must be different")
+	}
+
+	lastGames := DEFAULT_LAST_GAMES
+	if lastStr := request.QueryStringParameters["last"]; lastStr != "" {
+		last, err := strconv.Atoi(lastStr)
+		if err != nil || last < 0 {
+			return nil, http.StatusBadRequest, fmt.Errorf("failed to parse last: expected non-negative number")
+		}
+		if last > MAX_LAST_GAMES {
+			return nil, http.StatusBadRequest, fmt.Errorf("failed to parse last: maximum is %d", MAX_LAST_GAMES)
+		}
+		lastGames = last
+	}
+
+	userA, err := query.FetchByUsername(dynamoClient, ctx, USERTABLE, usernameA)
+	if err != nil {
+		return nil, http.StatusNotFound, fmt.Errorf("failed to fetch user '%s': %v", usernameA, err)
+	}
+	userB, err := query.FetchByUsername(dynamoClient, ctx, USERTABLE, usernameB)
+	if err != nil {
+		return nil, http.StatusNotFound, fmt.Errorf("failed to fetch user '%s': %v", usernameB, err)
+	}
+
+	games, truncated, err := query.FetchSharedGames(dynamoClient, ctx, PARTICIPATIONTABLE, GAMETABLE, userA.Subject, userB.Subject, MAX_HISTORY_GAMES)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch games: %v", err)
+	}
+
+	record := versus.CalculateVersus(userA, userB, games, lastGames)
+	record.Truncated = truncated
+	return &VersusResponse{
+		Message: "successfully calculated record",
+		Versus:  record,
+	}, http.StatusOK, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+var (
+	REGION             = os.Getenv("AWS_REGION")
+	USERTABLE          = os.Getenv("USERTABLE")
+	GAMETABLE          = os.Getenv("GAMETABLE")
+	PARTICIPATIONTABLE = os.Getenv("PARTICIPATIONTABLE")
+	DEFAULT_LAST_GAMES = 5   // default 5
+	MAX_LAST_GAMES     = 20  // default 20
+	MAX_HISTORY_GAMES  = 500 // default 500
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatalf("ERROR INITIALIZATION: %v\n", err)
+	}
+}
+
+func run() error {
+	awsConfig, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(REGION))
+	if err != nil {
+		return fmt.Errorf("failed to load aws config: %v", err)
+	}
+	dynamoClient := dynamodb.NewFromConfig(awsConfig)
+
+	if defaultLastGames, err := strconv.Atoi(os.Getenv("DEFAULT_LAST_GAMES")); err == nil {
+		DEFAULT_LAST_GAMES = defaultLastGames
+	}
+	if maxLastGames, err := strconv.Atoi(os.Getenv("MAX_LAST_GAMES")); err == nil {
+		MAX_LAST_GAMES = maxLastGames
+	}
+	if maxHistoryGames, err := strconv.Atoi(os.Getenv("MAX_HISTORY_GAMES")); err == nil {
+		MAX_HISTORY_GAMES = maxHistoryGames
+	}
+
+	lambda.Start(VersusHandler(dynamoClient))
+	return nil
+}
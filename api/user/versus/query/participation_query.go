@@ -0,0 +1,147 @@
+package query
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// maximum number of keys dynamodb accepts in one batch get.
+	MAX_BATCH_GET = 100
+)
+
+type participationOutput struct {
+	SortKey string `dynamodbav:"played_at_gameid"`
+	GameId  string `dynamodbav:"gameid"`
+}
+
+// filterParticipations returns the participations (in order) of which the subject also has a participation.
+// the sort key is identical for all participations of a game, so the participations of the subject are read by key.
+func filterParticipations(dynamoClient *dynamodb.Client, ctx context.Context, participationTableName, subject string, participations []participationOutput) ([]participationOutput, error) {
+	sortKeys := map[string]bool{}
+	for start := 0; start < len(participations); start += MAX_BATCH_GET {
+		end := min(start+MAX_BATCH_GET, len(participations))
+		keys := []map[string]types.AttributeValue{}
+		for _, participation := range participations[start:end] {
+			keys = append(keys, map[string]types.AttributeValue{
+				"subject":          &types.AttributeValueMemberS{Value: subject},
+				"played_at_gameid": &types.AttributeValueMemberS{Value: participation.SortKey},
+			})
+		}
+		requestItems := map[string]types.KeysAndAttributes{
+			participationTableName: {Keys: keys, ProjectionExpression: aws.String("played_at_gameid, gameid")},
+		}
+		for len(requestItems) > 0 {
+			output, err := dynamoClient.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+				RequestItems: requestItems,
+			})
+			if err != nil {
+				return nil, err
+			}
+			var page []participationOutput
+			if err := attributevalue.UnmarshalListOfMaps(output.Responses[participationTableName], &page); err != nil {
+				return nil, err
+			}
+			for _, participation := range page {
+				sortKeys[participation.SortKey] = true
+			}
+			requestItems = output.UnprocessedKeys
+		}
+	}
+
+	filtered := []participationOutput{}
+	for _, participation := range participations {
+		if sortKeys[participation.SortKey] {
+			filtered = append(filtered, participation)
+		}
+	}
+	return filtered, nil
+}
+
+// FetchSharedGames fetches the games both subjects participated in (latest games first) via the participant index.
+// only the latest games of subject a (up to limit) are considered, truncated reports whether older games were omitted.
+// games that no longer exist (e.g. expired games) are skipped.
+func FetchSharedGames(dynamoClient *dynamodb.Client, ctx context.Context, participationTableName, gameTableName, subjectA, subjectB string, limit int) ([]GameOutput, bool, error) {
+	shared := []participationOutput{}
+	read := 0
+	truncated := false
+	var lastEvaluatedKey map[string]types.AttributeValue
+	// one additional participation is read to detect whether the history is truncated.
+	for read <= limit {
+		output, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
+			TableName: aws.String(participationTableName),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":subject": &types.AttributeValueMemberS{Value: subjectA},
+			},
+			KeyConditionExpression: aws.String("subject = :subject"),
+			ProjectionExpression:   aws.String("played_at_gameid, gameid"),
+			ScanIndexForward:       aws.Bool(false),
+			Limit:                  aws.Int32(int32(limit + 1 - read)),
+			ExclusiveStartKey:      lastEvaluatedKey,
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		var page []participationOutput
+		if err := attributevalue.UnmarshalListOfMaps(output.Items, &page); err != nil {
+			return nil, false, err
+		}
+		read += len(page)
+		if read > limit {
+			truncated = true
+			page = page[:len(page)-(read-limit)]
+		}
+		sharedPage, err := filterParticipations(dynamoClient, ctx, participationTableName, subjectB, page)
+		if err != nil {
+			return nil, false, err
+		}
+		shared = append(shared, sharedPage...)
+
+		lastEvaluatedKey = output.LastEvaluatedKey
+		if len(lastEvaluatedKey) < 1 {
+			break
+		}
+	}
+
+	gamesById := map[string]GameOutput{}
+	for start := 0; start < len(shared); start += MAX_BATCH_GET {
+		end := min(start+MAX_BATCH_GET, len(shared))
+		keys := []map[string]types.AttributeValue{}
+		for _, participation := range shared[start:end] {
+			keys = append(keys, map[string]types.AttributeValue{
+				"gameid": &types.AttributeValueMemberS{Value: participation.GameId},
+			})
+		}
+		requestItems := map[string]types.KeysAndAttributes{
+			gameTableName: {Keys: keys},
+		}
+		for len(requestItems) > 0 {
+			output, err := dynamoClient.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+				RequestItems: requestItems,
+			})
+			if err != nil {
+				return nil, false, err
+			}
+			var page []GameOutput
+			if err := attributevalue.UnmarshalListOfMaps(output.Responses[gameTableName], &page); err != nil {
+				return nil, false, err
+			}
+			for _, game := range page {
+				gamesById[game.GameId] = game
+			}
+			requestItems = output.UnprocessedKeys
+		}
+	}
+
+	games := []GameOutput{}
+	for _, participation := range shared {
+		if game, ok := gamesById[participation.GameId]; ok {
+			games = append(games, game)
+		}
+	}
+	return games, truncated, nil
+}
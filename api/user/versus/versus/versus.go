@@ -0,0 +1,99 @@
+// contains the aggregation of the head-to-head record.
+// the record is computed on demand from the finalized games both players participated in.
+package versus
+
+import (
+	"github.com/megakuul/leaderboard/api/user/versus/query"
+)
+
+type PlayerOutput struct {
+	Username string `json:"username"`
+	Elo      int    `json:"elo"`
+}
+
+type SharedGameOutput struct {
+	GameId     string `json:"gameid"`
+	Date       string `json:"date"`
+	PlayedAt   string `json:"played_at"`
+	Together   bool   `json:"together"`
+	PlacementA int    `json:"placement_a"`
+	PlacementB int    `json:"placement_b"`
+	EloUpdateA int    `json:"elo_update_a"`
+	EloUpdateB int    `json:"elo_update_b"`
+}
+
+// VersusOutput contains the head-to-head record, truncated is set if older games exceeded the history limit and were not considered.
+type VersusOutput struct {
+	A             PlayerOutput       `json:"a"`
+	B             PlayerOutput       `json:"b"`
+	GamesAgainst  int                `json:"games_against"`
+	GamesTogether int                `json:"games_together"`
+	WinsA         int                `json:"wins_a"`
+	WinsB         int                `json:"wins_b"`
+	Draws         int                `json:"draws"`
+	WinsTogether  int                `json:"wins_together"`
+	NetEloA       int                `json:"net_elo_a"`
+	LastGames     []SharedGameOutput `json:"last_games"`
+	Truncated     bool               `json:"truncated"`
+}
+
+// CalculateVersus aggregates the head-to-head record of the users from the specified shared games (latest games first).
+// only finalized games that were not reverted are considered. in games against each other the side with
+// the better placement wins, the net elo is the sum of the elo updates of user a in these games.
+func CalculateVersus(userA, userB *query.UserOutput, games []query.GameOutput, lastGames int) *VersusOutput {
+	versus := &VersusOutput{
+		A:         PlayerOutput{Username: userA.Username, Elo: userA.Elo},
+		B:         PlayerOutput{Username: userB.Username, Elo: userB.Elo},
+		LastGames: []SharedGameOutput{},
+	}
+
+	for _, game := range games {
+		if !game.Readonly || game.Reverted {
+			continue
+		}
+		var partA, partB *query.ParticipantOutput
+		for _, part := range game.Participants {
+			switch part.Subject {
+			case userA.Subject:
+				partA = &part
+			case userB.Subject:
+				partB = &part
+			}
+		}
+		if partA == nil || partB == nil {
+			continue
+		}
+
+		together := partA.Team == partB.Team
+		if together {
+			versus.GamesTogether++
+			if partA.Placement == 1 {
+				versus.WinsTogether++
+			}
+		} else {
+			versus.GamesAgainst++
+			versus.NetEloA += partA.EloUpdate
+			if partA.Placement < partB.Placement {
+				versus.WinsA++
+			} else if partA.Placement > partB.Placement {
+				versus.WinsB++
+			} else {
+				versus.Draws++
+			}
+		}
+
+		if len(versus.LastGames) < lastGames {
+			versus.LastGames = append(versus.LastGames, SharedGameOutput{
+				GameId:     game.GameId,
+				Date:       game.Date,
+				PlayedAt:   game.PlayedAt,
+				Together:   together,
+				PlacementA: partA.Placement,
+				PlacementB: partB.Placement,
+				EloUpdateA: partA.EloUpdate,
+				EloUpdateB: partB.EloUpdate,
+			})
+		}
+	}
+	return versus
+}
@@ -0,0 +1,118 @@
+package versus
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/megakuul/leaderboard/api/user/versus/query"
+)
+
+func sharedGame(gameId string, participants ...query.ParticipantOutput) query.GameOutput {
+	game := query.GameOutput{GameId: gameId, Readonly: true, Participants: map[string]query.ParticipantOutput{}}
+	for _, part := range participants {
+		game.Participants[part.Subject] = part
+	}
+	return game
+}
+
+func TestCalculateVersus(t *testing.T) {
+	userA := &query.UserOutput{Subject: "a", Username: "alice", Elo: 1000}
+	userB := &query.UserOutput{Subject: "b", Username: "bob", Elo: 1100}
+	players := VersusOutput{
+		A: PlayerOutput{Username: "alice", Elo: 1000},
+		B: PlayerOutput{Username: "bob", Elo: 1100},
+	}
+
+	tests := []struct {
+		name      string
+		games     []query.GameOutput
+		lastGames int
+		want      func(v *VersusOutput)
+	}{
+		{
+			name:      "no shared games",
+			games:     nil,
+			lastGames: 5,
+			want:      func(v *VersusOutput) {},
+		},
+		{
+			name: "skips unfinalized, reverted and deleted participants",
+			games: []query.GameOutput{
+				{GameId: "g1", Participants: map[string]query.ParticipantOutput{
+					"a": {Subject: "a", Team: 1, Placement: 1},
+					"b": {Subject: "b", Team: 2, Placement: 2},
+				}},
+				{GameId: "g2", Readonly: true, Reverted: true, Participants: map[string]query.ParticipantOutput{
+					"a": {Subject: "a", Team: 1, Placement: 1},
+					"b": {Subject: "b", Team: 2, Placement: 2},
+				}},
+				// b was anonymized in this game after deleting the account.
+				sharedGame("g3",
+					query.ParticipantOutput{Subject: "a", Team: 1, Placement: 1},
+					query.ParticipantOutput{Subject: "deleted-01", Username: "[deleted]", Team: 2, Placement: 2},
+				),
+			},
+			lastGames: 5,
+			want:      func(v *VersusOutput) {},
+		},
+		{
+			name: "wins, draws and net elo against each other",
+			games: []query.GameOutput{
+				sharedGame("g1",
+					query.ParticipantOutput{Subject: "a", Team: 1, Placement: 1, EloUpdate: 12},
+					query.ParticipantOutput{Subject: "b", Team: 2, Placement: 2, EloUpdate: -12},
+				),
+				sharedGame("g2",
+					query.ParticipantOutput{Subject: "a", Team: 1, Placement: 3, EloUpdate: -8},
+					query.ParticipantOutput{Subject: "b", Team: 2, Placement: 2, EloUpdate: 4},
+				),
+				// tied placements are counted as draw.
+				sharedGame("g3",
+					query.ParticipantOutput{Subject: "a", Team: 1, Placement: 2, EloUpdate: 1},
+					query.ParticipantOutput{Subject: "b", Team: 2, Placement: 2, EloUpdate: -1},
+				),
+			},
+			lastGames: 5,
+			want: func(v *VersusOutput) {
+				v.GamesAgainst, v.WinsA, v.WinsB, v.Draws, v.NetEloA = 3, 1, 1, 1, 5
+				v.LastGames = []SharedGameOutput{
+					{GameId: "g1", PlacementA: 1, PlacementB: 2, EloUpdateA: 12, EloUpdateB: -12},
+					{GameId: "g2", PlacementA: 3, PlacementB: 2, EloUpdateA: -8, EloUpdateB: 4},
+					{GameId: "g3", PlacementA: 2, PlacementB: 2, EloUpdateA: 1, EloUpdateB: -1},
+				}
+			},
+		},
+		{
+			name: "games on the same team are not counted against each other",
+			games: []query.GameOutput{
+				sharedGame("g1",
+					query.ParticipantOutput{Subject: "a", Team: 1, Placement: 1, EloUpdate: 10},
+					query.ParticipantOutput{Subject: "b", Team: 1, Placement: 1, EloUpdate: 10},
+					query.ParticipantOutput{Subject: "c", Team: 2, Placement: 2, EloUpdate: -20},
+				),
+				sharedGame("g2",
+					query.ParticipantOutput{Subject: "a", Team: 2, Placement: 2, EloUpdate: -5},
+					query.ParticipantOutput{Subject: "b", Team: 2, Placement: 2, EloUpdate: -5},
+					query.ParticipantOutput{Subject: "c", Team: 1, Placement: 1, EloUpdate: 10},
+				),
+			},
+			lastGames: 1,
+			want: func(v *VersusOutput) {
+				v.GamesTogether, v.WinsTogether = 2, 1
+				v.LastGames = []SharedGameOutput{
+					{GameId: "g1", Together: true, PlacementA: 1, PlacementB: 1, EloUpdateA: 10, EloUpdateB: 10},
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := players
+			want.LastGames = []SharedGameOutput{}
+			tt.want(&want)
+			if got := CalculateVersus(userA, userB, tt.games, tt.lastGames); !reflect.DeepEqual(got, &want) {
+				t.Errorf("CalculateVersus() = %+v, want %+v", got, &want)
+			}
+		})
+	}
+}
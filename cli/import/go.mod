@@ -0,0 +1,3 @@
+module github.com/megakuul/leaderboard/cli/import
+
+go 1.22.5
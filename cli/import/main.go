@@ -0,0 +1,115 @@
+// import is a small command line client for the bulk game import endpoint.
+// it uploads a csv or json file to /api/game/import and prints the per-row errors reported by the api.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type importError struct {
+	Row     int    `json:"row"`
+	Game    string `json:"game"`
+	Message string `json:"message"`
+}
+
+type importResponse struct {
+	Message string        `json:"message"`
+	GameIds []string      `json:"gameids"`
+	Errors  []importError `json:"errors"`
+}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	file := flag.String("file", "", "csv or json file containing the games to import")
+	endpoint := flag.String("endpoint", "", "base url of the leaderboard (e.g. https://leaderboard.example.com)")
+	token := flag.String("token", os.Getenv("LEADERBOARD_TOKEN"), "cognito id_token used for authorization (defaults to $LEADERBOARD_TOKEN)")
+	preconfirmed := flag.Bool("preconfirmed", false, "import the games as confirmed games (requires admin privileges)")
+	flag.Parse()
+
+	if *file == "" || *endpoint == "" {
+		flag.Usage()
+		return fmt.Errorf("-file and -endpoint are required")
+	}
+	if *token == "" {
+		return fmt.Errorf("no token provided, use -token or set LEADERBOARD_TOKEN")
+	}
+
+	body, err := os.ReadFile(*file)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %v", err)
+	}
+
+	contentType := "application/json"
+	if strings.EqualFold(filepath.Ext(*file), ".csv") {
+		contentType = "text/csv"
+	}
+
+	importUrl, err := url.JoinPath(*endpoint, "/api/game/import")
+	if err != nil {
+		return fmt.Errorf("invalid endpoint: %v", err)
+	}
+	if *preconfirmed {
+		importUrl += "?preconfirmed=true"
+	}
+
+	request, err := http.NewRequest(http.MethodPost, importUrl, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	request.Header.Set("Content-Type", contentType)
+	request.Header.Set("Authorization", "Bearer "+*token)
+
+	client := http.Client{Timeout: 60 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %v", err)
+	}
+
+	if !strings.HasPrefix(response.Header.Get("Content-Type"), "application/json") {
+		return fmt.Errorf("import failed (%d): %s", response.StatusCode, strings.TrimSpace(string(responseBody)))
+	}
+
+	var result importResponse
+	if err := json.Unmarshal(responseBody, &result); err != nil {
+		return fmt.Errorf("failed to deserialize response: %v", err)
+	}
+
+	fmt.Println(result.Message)
+	for _, gameid := range result.GameIds {
+		fmt.Printf("  imported: %s\n", gameid)
+	}
+	for _, importErr := range result.Errors {
+		if importErr.Row > 0 {
+			fmt.Printf("  row %d (game %s): %s\n", importErr.Row, importErr.Game, importErr.Message)
+		} else {
+			fmt.Printf("  %s\n", importErr.Message)
+		}
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("import failed with status %d", response.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,426 @@
+// usernames is a migration tool for the username reservations.
+// it scans the user table, reports usernames that are shared by multiple users and
+// optionally reserves all unique usernames in the reservation table and backfills the search keys, the visibility, the rank counters and the participant index.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type user struct {
+	Subject   string `dynamodbav:"subject"`
+	Username  string `dynamodbav:"username"`
+	SearchKey string `dynamodbav:"search_key"`
+	Region    string `dynamodbav:"user_region"`
+	Elo       int    `dynamodbav:"elo"`
+	Disabled  bool   `dynamodbav:"disabled"`
+	Private   bool   `dynamodbav:"private"`
+}
+
+type gameParticipant struct {
+	Subject  string `dynamodbav:"subject"`
+	Username string `dynamodbav:"username"`
+	Deleted  bool   `dynamodbav:"deleted"`
+}
+
+type game struct {
+	GameId       string                     `dynamodbav:"gameid"`
+	Date         string                     `dynamodbav:"game_date"`
+	PlayedAt     string                     `dynamodbav:"played_at"`
+	Participants map[string]gameParticipant `dynamodbav:"participants"`
+}
+
+// rankBucket identifies the rank counter of the users with the elo in the region.
+type rankBucket struct {
+	Region string
+	Elo    int
+}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	region := flag.String("region", os.Getenv("AWS_REGION"), "aws region of the leaderboard tables (defaults to $AWS_REGION)")
+	userTable := flag.String("user-table", "leaderboard-users", "name of the user table")
+	reservationTable := flag.String("reservation-table", "leaderboard-username-reservations", "name of the username reservation table")
+	reserve := flag.Bool("reserve", false, "reserve all unique usernames (duplicates are only reported)")
+	backfillSearch := flag.Bool("backfill-search", false, "set the search keys of users that were not updated since the username search was introduced")
+	backfillVisibility := flag.Bool("backfill-visibility", false, "hide disabled and private users that were not updated since unlisted users are removed from the leaderboard and search indexes")
+	rankCountTable := flag.String("rank-count-table", "leaderboard-rank-counts", "name of the rank counter table")
+	backfillRankCounts := flag.Bool("backfill-rank-counts", false, "recount the listed users per region and elo into the rank counters (run it once after the rank counters were introduced)")
+	gameTable := flag.String("game-table", "leaderboard-games", "name of the game table")
+	participationTable := flag.String("participation-table", "leaderboard-participations", "name of the participation table")
+	backfillParticipations := flag.Bool("backfill-participations", false, "write the participations of games that were submitted before the participant index was introduced")
+	flag.Parse()
+
+	ctx := context.Background()
+	awsConfig, err := config.LoadDefaultConfig(ctx, config.WithRegion(*region))
+	if err != nil {
+		return fmt.Errorf("failed to load aws config: %v", err)
+	}
+	dynamoClient := dynamodb.NewFromConfig(awsConfig)
+
+	users, err := scanUsers(dynamoClient, ctx, *userTable)
+	if err != nil {
+		return fmt.Errorf("failed to scan users: %v", err)
+	}
+
+	// usernames are reserved by their normalized key, usernames that only differ in case or whitespace are duplicates.
+	usersByName := map[string][]user{}
+	for _, u := range users {
+		key := normalizeSearchKey(u.Username)
+		usersByName[key] = append(usersByName[key], u)
+	}
+	usernames := []string{}
+	for username := range usersByName {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+
+	fmt.Printf("scanned %d users with %d distinct usernames\n", len(users), len(usernames))
+
+	duplicates := 0
+	for _, username := range usernames {
+		holders := usersByName[username]
+		if len(holders) < 2 {
+			continue
+		}
+		duplicates++
+		fmt.Printf("duplicate username '%s' (%d users):\n", username, len(holders))
+		for _, holder := range holders {
+			fmt.Printf("  subject=%s username='%s' region=%s elo=%d\n", holder.Subject, holder.Username, holder.Region, holder.Elo)
+		}
+	}
+	fmt.Printf("found %d duplicate usernames\n", duplicates)
+	if duplicates > 0 {
+		fmt.Println("duplicate users must change their preferred_username, the first user that updates afterwards holds the username")
+	}
+
+	if *backfillSearch {
+		backfilled := 0
+		for _, u := range users {
+			searchKey := normalizeSearchKey(u.Username)
+			// unlisted users are not indexed in the search.
+			if u.Disabled || u.Private || u.SearchKey == searchKey || searchKey == "" {
+				continue
+			}
+			if err := setSearchKey(dynamoClient, ctx, *userTable, u.Subject, u.Username, searchKey); err != nil {
+				var conditionErr *types.ConditionalCheckFailedException
+				if errors.As(err, &conditionErr) {
+					// the user was renamed in the meantime, the update already set the search key.
+					continue
+				}
+				return fmt.Errorf("failed to set search key of '%s': %v", u.Username, err)
+			}
+			backfilled++
+		}
+		fmt.Printf("backfilled %d search keys\n", backfilled)
+	}
+
+	if *backfillVisibility {
+		hidden := 0
+		for _, u := range users {
+			if !(u.Disabled || u.Private) || (u.Region == "" && u.SearchKey == "") {
+				continue
+			}
+			if err := hideUser(dynamoClient, ctx, *userTable, u.Subject); err != nil {
+				var conditionErr *types.ConditionalCheckFailedException
+				if errors.As(err, &conditionErr) {
+					// the user was listed again in the meantime.
+					continue
+				}
+				return fmt.Errorf("failed to hide '%s': %v", u.Username, err)
+			}
+			hidden++
+		}
+		fmt.Printf("hid %d unlisted users\n", hidden)
+	}
+
+	if *backfillRankCounts {
+		counts := map[rankBucket]int{}
+		for _, u := range users {
+			// users hidden by the visibility backfill are no longer listed.
+			if u.Region == "" || (*backfillVisibility && (u.Disabled || u.Private)) {
+				continue
+			}
+			counts[rankBucket{Region: u.Region, Elo: u.Elo}]++
+		}
+		for bucket, count := range counts {
+			if err := setRankCount(dynamoClient, ctx, *rankCountTable, bucket, count); err != nil {
+				return fmt.Errorf("failed to set rank counter of elo %d in '%s': %v", bucket.Elo, bucket.Region, err)
+			}
+		}
+		fmt.Printf("backfilled %d rank counters\n", len(counts))
+	}
+
+	if *backfillParticipations {
+		written, err := backfillGameParticipations(dynamoClient, ctx, *gameTable, *participationTable)
+		if err != nil {
+			return fmt.Errorf("failed to backfill participations: %v", err)
+		}
+		fmt.Printf("backfilled %d participations\n", written)
+	}
+
+	if !*reserve {
+		return nil
+	}
+
+	reserved, conflicts := 0, 0
+	for _, username := range usernames {
+		holders := usersByName[username]
+		if len(holders) != 1 {
+			continue
+		}
+		err := reserveUsername(dynamoClient, ctx, *reservationTable, username, holders[0].Subject)
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			conflicts++
+			fmt.Printf("conflict: username '%s' is reserved by another user\n", username)
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to reserve '%s': %v", username, err)
+		}
+		reserved++
+	}
+	fmt.Printf("reserved %d usernames, %d conflicts\n", reserved, conflicts)
+	return nil
+}
+
+// scanUsers reads all users of the user table.
+func scanUsers(dynamoClient *dynamodb.Client, ctx context.Context, tableName string) ([]user, error) {
+	users := []user{}
+	var lastEvaluatedKey map[string]types.AttributeValue
+	for {
+		output, err := dynamoClient.Scan(ctx, &dynamodb.ScanInput{
+			TableName: aws.String(tableName),
+			ExpressionAttributeNames: map[string]string{
+				"#subject":     "subject",
+				"#username":    "username",
+				"#user_region": "user_region",
+				"#elo":         "elo",
+				"#search_key":  "search_key",
+				"#disabled":    "disabled",
+				"#private":     "private",
+			},
+			ProjectionExpression: aws.String("#subject, #username, #user_region, #elo, #search_key, #disabled, #private"),
+			ExclusiveStartKey:    lastEvaluatedKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		var page []user
+		if err := attributevalue.UnmarshalListOfMaps(output.Items, &page); err != nil {
+			return nil, err
+		}
+		for _, u := range page {
+			// users without username never completed an update and hold no reservation.
+			if u.Username != "" {
+				users = append(users, u)
+			}
+		}
+
+		lastEvaluatedKey = output.LastEvaluatedKey
+		if len(lastEvaluatedKey) < 1 {
+			break
+		}
+	}
+	return users, nil
+}
+
+// reserveUsername reserves the normalized username for the subject, existing reservations of the subject are kept.
+func reserveUsername(dynamoClient *dynamodb.Client, ctx context.Context, tableName, username, subject string) error {
+	_, err := dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(tableName),
+		Item: map[string]types.AttributeValue{
+			"username": &types.AttributeValueMemberS{Value: username},
+			"subject":  &types.AttributeValueMemberS{Value: subject},
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":subject": &types.AttributeValueMemberS{Value: subject},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(username) OR subject = :subject"),
+	})
+	return err
+}
+
+// normalizeSearchKey normalizes the username like the user update (lowercased, collapsed whitespace).
+func normalizeSearchKey(username string) string {
+	return strings.Join(strings.Fields(strings.ToLower(username)), " ")
+}
+
+// setSearchKey sets the search key of the user if the username was not changed in the meantime.
+func setSearchKey(dynamoClient *dynamodb.Client, ctx context.Context, tableName, subject, username, searchKey string) error {
+	prefix, _ := utf8.DecodeRuneInString(searchKey)
+	_, err := dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"subject": &types.AttributeValueMemberS{Value: subject},
+		},
+		ExpressionAttributeNames: map[string]string{
+			"#username":      "username",
+			"#search_prefix": "search_prefix",
+			"#search_key":    "search_key",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":username":      &types.AttributeValueMemberS{Value: username},
+			":search_prefix": &types.AttributeValueMemberS{Value: string(prefix)},
+			":search_key":    &types.AttributeValueMemberS{Value: searchKey},
+		},
+		ConditionExpression: aws.String("#username = :username"),
+		UpdateExpression:    aws.String("SET #search_prefix = :search_prefix, #search_key = :search_key"),
+	})
+	return err
+}
+
+// hideUser removes the index attributes of a disabled or private user, this drops the user from the sparse region_gsi and search_gsi.
+func hideUser(dynamoClient *dynamodb.Client, ctx context.Context, tableName, subject string) error {
+	_, err := dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"subject": &types.AttributeValueMemberS{Value: subject},
+		},
+		ExpressionAttributeNames: map[string]string{
+			"#disabled":      "disabled",
+			"#private":       "private",
+			"#user_region":   "user_region",
+			"#search_prefix": "search_prefix",
+			"#search_key":    "search_key",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":hidden": &types.AttributeValueMemberBOOL{Value: true},
+		},
+		ConditionExpression: aws.String("#disabled = :hidden OR #private = :hidden"),
+		UpdateExpression:    aws.String("REMOVE #user_region, #search_prefix, #search_key"),
+	})
+	return err
+}
+
+// setRankCount overwrites the rank counter of the bucket with the counted users.
+func setRankCount(dynamoClient *dynamodb.Client, ctx context.Context, tableName string, bucket rankBucket, count int) error {
+	_, err := dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(tableName),
+		Item: map[string]types.AttributeValue{
+			"user_region": &types.AttributeValueMemberS{Value: bucket.Region},
+			"elo":         &types.AttributeValueMemberN{Value: strconv.Itoa(bucket.Elo)},
+			"count":       &types.AttributeValueMemberN{Value: strconv.Itoa(count)},
+		},
+	})
+	return err
+}
+
+// backfillGameParticipations scans the game table and writes the participation of every participant.
+// games without played_at (submitted before it was recorded) are set to the start of their date first,
+// so that the sort key matches the one derived by the edit and expire functions.
+// the participations are overwritten, therefore the backfill can be repeated.
+func backfillGameParticipations(dynamoClient *dynamodb.Client, ctx context.Context, gameTableName, participationTableName string) (int, error) {
+	written := 0
+	var lastEvaluatedKey map[string]types.AttributeValue
+	for {
+		output, err := dynamoClient.Scan(ctx, &dynamodb.ScanInput{
+			TableName: aws.String(gameTableName),
+			ExpressionAttributeNames: map[string]string{
+				"#gameid":       "gameid",
+				"#game_date":    "game_date",
+				"#played_at":    "played_at",
+				"#participants": "participants",
+			},
+			ProjectionExpression: aws.String("#gameid, #game_date, #played_at, #participants"),
+			ExclusiveStartKey:    lastEvaluatedKey,
+		})
+		if err != nil {
+			return written, err
+		}
+		var games []game
+		if err := attributevalue.UnmarshalListOfMaps(output.Items, &games); err != nil {
+			return written, err
+		}
+		for _, g := range games {
+			playedAt, err := time.Parse(time.RFC3339, g.PlayedAt)
+			if err != nil {
+				playedAt, err = time.Parse("2006-01-02", g.Date)
+				if err != nil {
+					fmt.Printf("skipping game %s: no valid played_at or game_date\n", g.GameId)
+					continue
+				}
+				if err := setPlayedAt(dynamoClient, ctx, gameTableName, g.GameId, playedAt); err != nil {
+					var conditionErr *types.ConditionalCheckFailedException
+					if errors.As(err, &conditionErr) {
+						// the game was removed or played_at was set in the meantime, it is picked up by a repeated backfill.
+						continue
+					}
+					return written, fmt.Errorf("failed to set played_at of game %s: %v", g.GameId, err)
+				}
+			}
+			sortKey := fmt.Sprintf("%s#%s", playedAt.UTC().Format(time.RFC3339), g.GameId)
+			for _, part := range g.Participants {
+				// anonymized entries have no participation.
+				if part.Deleted || part.Subject == "" {
+					continue
+				}
+				if err := putParticipation(dynamoClient, ctx, participationTableName, part, sortKey, g.GameId, g.Date); err != nil {
+					return written, fmt.Errorf("failed to write participation of game %s: %v", g.GameId, err)
+				}
+				written++
+			}
+		}
+
+		lastEvaluatedKey = output.LastEvaluatedKey
+		if len(lastEvaluatedKey) < 1 {
+			break
+		}
+	}
+	return written, nil
+}
+
+// setPlayedAt sets the played_at of a game that was submitted before it was recorded.
+func setPlayedAt(dynamoClient *dynamodb.Client, ctx context.Context, tableName, gameId string, playedAt time.Time) error {
+	_, err := dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"gameid": &types.AttributeValueMemberS{Value: gameId},
+		},
+		ExpressionAttributeNames: map[string]string{
+			"#played_at": "played_at",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":played_at": &types.AttributeValueMemberS{Value: playedAt.Format(time.RFC3339)},
+		},
+		ConditionExpression: aws.String("attribute_exists(gameid) AND attribute_not_exists(#played_at)"),
+		UpdateExpression:    aws.String("SET #played_at = :played_at"),
+	})
+	return err
+}
+
+// putParticipation writes the participation of the participant like the add function does.
+func putParticipation(dynamoClient *dynamodb.Client, ctx context.Context, tableName string, part gameParticipant, sortKey, gameId, date string) error {
+	_, err := dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(tableName),
+		Item: map[string]types.AttributeValue{
+			"subject":          &types.AttributeValueMemberS{Value: part.Subject},
+			"played_at_gameid": &types.AttributeValueMemberS{Value: sortKey},
+			"gameid":           &types.AttributeValueMemberS{Value: gameId},
+			"username":         &types.AttributeValueMemberS{Value: part.Username},
+			"game_date":        &types.AttributeValueMemberS{Value: date},
+		},
+	})
+	return err
+}
@@ -4,16 +4,19 @@ import { RequestTokens } from "./auth";
  * @typedef {Object} FetchUserResponseUser
  * @property {string} username
  * @property {boolean} disabled
+ * @property {boolean} private
  * @property {string} region
  * @property {string} title
  * @property {string} iconurl
  * @property {number} elo
+ * @property {number} rank
  */
 
 /**
  * @typedef {Object} FetchUserResponse
  * @property {string} message
  * @property {string} newpagekey
+ * @property {string} prevpagekey
  * @property {FetchUserResponseUser[]} users
  */
 
@@ -47,11 +50,131 @@ export const FetchUser = async (region, pagesize="", username="", elo="", lastpa
   }
 }
 
+/**
+ * Fetches the user together with the friends of the user ranked by elo.
+ * https://github.com/Megakuul/leaderboard/blob/main/README.md#api
+ * @returns {Promise<FetchUserResponse>} if api call succeeds.
+ * @throws {Error} if api call failed.
+ */
+export const FetchFriendUsers = async () => {
+  const devUrl = import.meta.env.VITE_DEV_API_URL;
+  const res = await fetch(`${devUrl?devUrl:""}/api/user/fetch?scope=friends`, {
+    method: "GET",
+    headers: {
+      Authorization: `Bearer ${localStorage.getItem("id_token")}`
+    },
+  })
+  if (res.ok) {
+    return await res.json();
+  } else if (res.status === 401) {
+    RequestTokens()
+  } else {
+    throw new Error(await res.text())
+  }
+}
+
+/**
+ * @typedef {Object} FetchFriendsResponseFriend
+ * @property {string} username
+ * @property {"accepted"|"outgoing"|"incoming"} status
+ * @property {number} updated_at
+ */
+
+/**
+ * @typedef {Object} FetchFriendsResponse
+ * @property {string} message
+ * @property {FetchFriendsResponseFriend[]} friends
+ */
+
+/**
+ * Fetches the friends and pending friend requests of the user.
+ * https://github.com/Megakuul/leaderboard/blob/main/README.md#api
+ * @returns {Promise<FetchFriendsResponse>} if api call succeeds.
+ * @throws {Error} if api call failed.
+ */
+export const FetchFriends = async () => {
+  const devUrl = import.meta.env.VITE_DEV_API_URL;
+  const res = await fetch(`${devUrl?devUrl:""}/api/friend/fetch`, {
+    method: "GET",
+    headers: {
+      Authorization: `Bearer ${localStorage.getItem("id_token")}`
+    },
+  })
+  if (res.ok) {
+    return await res.json();
+  } else if (res.status === 401) {
+    RequestTokens()
+  } else {
+    throw new Error(await res.text())
+  }
+}
+
+/**
+ * @typedef {Object} RequestFriendResponse
+ * @property {string} message
+ * @property {"accepted"|"outgoing"} status
+ */
+
+/**
+ * Sends a friend request to the user or accepts the request of the user.
+ * https://github.com/Megakuul/leaderboard/blob/main/README.md#api
+ * @param {string} username
+ * @returns {Promise<RequestFriendResponse>} if api call succeeds.
+ * @throws {Error} if api call failed.
+ */
+export const RequestFriend = async (username) => {
+  const params = new URLSearchParams({
+    username: username,
+  })
+  const devUrl = import.meta.env.VITE_DEV_API_URL;
+  const res = await fetch(`${devUrl?devUrl:""}/api/friend/request?${params.toString()}`, {
+    method: "POST",
+    headers: {
+      Authorization: `Bearer ${localStorage.getItem("id_token")}`
+    },
+  })
+  if (res.ok) {
+    return await res.json();
+  } else if (res.status === 401) {
+    RequestTokens()
+  } else {
+    throw new Error(await res.text())
+  }
+}
+
+/**
+ * Removes the friend or the pending friend request of the user.
+ * https://github.com/Megakuul/leaderboard/blob/main/README.md#api
+ * @param {string} username
+ * @returns {Promise<string>} if api call succeeds.
+ * @throws {Error} if api call failed.
+ */
+export const RemoveFriend = async (username) => {
+  const params = new URLSearchParams({
+    username: username,
+  })
+  const devUrl = import.meta.env.VITE_DEV_API_URL;
+  const res = await fetch(`${devUrl?devUrl:""}/api/friend/remove?${params.toString()}`, {
+    method: "DELETE",
+    headers: {
+      Authorization: `Bearer ${localStorage.getItem("id_token")}`
+    },
+  })
+  if (res.ok) {
+    return await res.text();
+  } else if (res.status === 401) {
+    RequestTokens()
+  } else {
+    throw new Error(await res.text())
+  }
+}
+
 /**
  * @typedef {Object} UpdateUserRequestUser
  * @property {string} title
  * @property {string} iconurl
  * @property {boolean} disabled
+ * @property {boolean} private
  */
 
 /**
@@ -63,6 +186,7 @@ export const FetchUser = async (region, pagesize="", username="", elo="", lastpa
  * @typedef {Object} UpdateUserResponseUser
  * @property {string} username
  * @property {boolean} disabled
+ * @property {boolean} private
  * @property {string} region
  * @property {string} title
  * @property {string} email
@@ -102,9 +226,62 @@ export const UpdateUser = async (userData) => {
 }
 
 
+/**
+ * @typedef {Object} UploadAvatarResponseUpload
+ * @property {string} upload_url
+ * @property {string} upload_method
+ * @property {Object<string, string>} upload_headers
+ * @property {number} expires_at
+ */
+
+/**
+ * @typedef {Object} UploadAvatarResponse
+ * @property {string} message
+ * @property {UploadAvatarResponseUpload} upload
+ */
+
+/**
+ * Uploads an avatar image, the iconurl of the user is set after the avatar was processed.
+ * https://github.com/Megakuul/leaderboard/blob/main/README.md#api
+ * @param {File} file
+ * @returns {Promise<UploadAvatarResponse>} if api call succeeds.
+ * @throws {Error} if api call failed.
+ */
+export const UploadAvatar = async (file) => {
+  const devUrl = import.meta.env.VITE_DEV_API_URL;
+  const res = await fetch(`${devUrl?devUrl:""}/api/user/avatar`, {
+    method: "POST",
+    headers: {
+      Authorization: `Bearer ${localStorage.getItem("id_token")}`
+    },
+    body: JSON.stringify({
+      content_type: file.type,
+      content_length: file.size,
+    }),
+  })
+  if (res.status === 401) {
+    RequestTokens()
+    return;
+  } else if (!res.ok) {
+    throw new Error(await res.text());
+  }
+  /** @type {UploadAvatarResponse} */
+  const response = await res.json();
+  const uploadRes = await fetch(response.upload.upload_url, {
+    method: response.upload.upload_method,
+    headers: response.upload.upload_headers,
+    body: file,
+  })
+  if (!uploadRes.ok) {
+    throw new Error(`failed to upload avatar: ${uploadRes.status}`);
+  }
+  return response;
+}
+
 /**
  * @typedef {Object} FetchGameResponseParticipant
  * @property {string} username
+ * @property {string} current_username
  * @property {boolean} underdog
  * @property {number} team
  * @property {number} placement
@@ -112,6 +289,7 @@ export const UpdateUser = async (userData) => {
  * @property {number} elo
  * @property {number} elo_update
  * @property {boolean} confirmed
+ * @property {boolean} [deleted]
  */
 
 /**
@@ -120,7 +298,7 @@ export const UpdateUser = async (userData) => {
  * @property {string} date
  * @property {boolean} readonly
  * @property {number} expires_in
- * @property {Object.<string, FetchGameResponseParticipant>} participants
+ * @property {FetchGameResponseParticipant[]} participants
  */
 
 /**